@@ -7,4 +7,5 @@ require (
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/rs/zerolog v1.26.1
 	github.com/usaafko/vmmanager6-api-go v0.0.24
+	golang.org/x/crypto v0.0.0-20211215165025-cf75a172585e
 )
@@ -0,0 +1,611 @@
+package vmmanager6
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VMmanager6 API requests, that are not implemented in vmmanager6-api-go.
+// Client shares the session with vmmanager6-api-go client.
+type apiClient struct {
+	url          string
+	httpClient   *http.Client
+	token        string
+	timeout      int
+	pollInterval time.Duration
+}
+
+// Failed request, Code and Msg are from VMmanager6 error answer if there is one
+type apiError struct {
+	Method string
+	Path   string
+	Status int
+	Code   int
+	Msg    string
+}
+
+func (e *apiError) Error() string {
+	if e.Msg != "" {
+		return fmt.Sprintf("%s %s: %s (code %v)", e.Method, e.Path, e.Msg, e.Code)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.Status, http.StatusText(e.Status))
+}
+
+// Object or API method doesn't exist
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.Status == http.StatusNotFound
+}
+
+func newApiClient(pm_api_url string, tlsconf *tls.Config, timeout int) (*apiClient, error) {
+	apiUrl, err := url.Parse(pm_api_url)
+	if err != nil {
+		return nil, err
+	}
+	if apiUrl.Scheme == "" || apiUrl.Host == "" {
+		return nil, fmt.Errorf("pm_api_url %q must be like https://host.fqdn/vm/v3", pm_api_url)
+	}
+	return &apiClient{
+		// all VMmanager6 services (vm, ip, auth) are on the same host
+		url: apiUrl.Scheme + "://" + apiUrl.Host,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsconf,
+			},
+			Timeout: time.Duration(timeout) * time.Second,
+		},
+		timeout:      timeout,
+		pollInterval: 5 * time.Second,
+	}, nil
+}
+
+func (c *apiClient) login(email string, password string) error {
+	var answer struct {
+		Token string `json:"token"`
+	}
+	err := c.post("/auth/v4/public/token", map[string]interface{}{
+		"email":    email,
+		"password": password,
+	}, &answer)
+	if err != nil {
+		return err
+	}
+	c.token = answer.Token
+	return nil
+}
+
+func (c *apiClient) setApiToken(token string) {
+	c.token = token
+}
+
+func (c *apiClient) request(method string, path string, params interface{}, answer interface{}) error {
+	var body io.Reader
+	if params != nil {
+		j, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(j)
+	}
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if params != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("x-xsrf-token", c.token)
+		req.AddCookie(&http.Cookie{Name: "ses6", Value: c.token})
+	}
+	logger, _ := CreateSubLogger("api")
+	logger.Debug().Msgf("%s %s", method, path)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var answer struct {
+			Error struct {
+				Code int    `json:"code"`
+				Msg  string `json:"msg"`
+			} `json:"error"`
+		}
+		json.Unmarshal(data, &answer)
+		return &apiError{
+			Method: method,
+			Path:   path,
+			Status: resp.StatusCode,
+			Code:   answer.Error.Code,
+			Msg:    answer.Error.Msg,
+		}
+	}
+	if answer != nil && len(data) > 0 {
+		return json.Unmarshal(data, answer)
+	}
+	return nil
+}
+
+func (c *apiClient) get(path string, answer interface{}) error {
+	return c.request(http.MethodGet, path, nil, answer)
+}
+
+func (c *apiClient) post(path string, params interface{}, answer interface{}) error {
+	return c.request(http.MethodPost, path, params, answer)
+}
+
+func (c *apiClient) delete(path string) error {
+	return c.request(http.MethodDelete, path, nil, nil)
+}
+
+func (c *apiClient) getMap(path string) (map[string]interface{}, error) {
+	var answer map[string]interface{}
+	err := c.get(path, &answer)
+	return answer, err
+}
+
+// VMmanager6 returns collections as {"list": [...]}
+func (c *apiClient) getList(path string) ([]map[string]interface{}, error) {
+	var answer struct {
+		List []map[string]interface{} `json:"list"`
+	}
+	err := c.get(path, &answer)
+	return answer.List, err
+}
+
+// VMmanager6 answers {"id": N} on create
+func (c *apiClient) create(path string, params interface{}) (string, error) {
+	var answer struct {
+		Id int `json:"id"`
+	}
+	err := c.post(path, params, &answer)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(answer.Id), nil
+}
+
+// Preset, node and os are checked in vmmanager6_vm_qemu plan
+func (c *apiClient) GetPresetInfo(id int) (map[string]interface{}, error) {
+	return c.getMap(fmt.Sprintf("/vm/v3/preset/%d", id))
+}
+
+func (c *apiClient) GetNodeInfo(id int) (map[string]interface{}, error) {
+	return c.getMap(fmt.Sprintf("/vm/v3/node/%d", id))
+}
+
+func (c *apiClient) GetOsInfo(id int) (map[string]interface{}, error) {
+	return c.getMap(fmt.Sprintf("/vm/v3/os/%d", id))
+}
+
+// VM creation and OS reinstall with cloud-init and recipe params,
+// ConfigNewQemu and ReinstallOS of vmmanager6-api-go don't have them
+type vmRecipe struct {
+	Recipe       int                 `json:"recipe"`
+	RecipeParams []map[string]string `json:"recipe_params,omitempty"`
+}
+
+type vmSshKey struct {
+	Id        int    `json:"ssh_pub_key_id,omitempty"`
+	SshPubKey string `json:"ssh_pub_key,omitempty"`
+}
+
+type vmCloudInit struct {
+	User        string `json:"user,omitempty"`
+	UserData    string `json:"user_data,omitempty"`
+	NetworkData string `json:"network_data,omitempty"`
+}
+
+// Same keys as in custom_interfaces and vxlan of vmmanager6_vm_qemu
+type vmCustomInterface struct {
+	Bridge  string `json:"bridge"`
+	IpName  string `json:"ip_name,omitempty"`
+	IpPool  int    `json:"ippool,omitempty"`
+	IpCount int    `json:"ip_count"`
+}
+
+type vmVxlan struct {
+	Id    int `json:"id"`
+	Ipnet int `json:"ipnet"`
+	IPv4  int `json:"ipv4_number"`
+}
+
+type vmCreateConfig struct {
+	Name             string              `json:"name"`
+	Description      string              `json:"comment"`
+	Memory           int                 `json:"ram_mib"`
+	Cores            int                 `json:"cpu_number"`
+	Disk             int                 `json:"hdd_mib"`
+	Cluster          int                 `json:"cluster"`
+	Node             int                 `json:"node,omitempty"`
+	Account          int                 `json:"account"`
+	Domain           string              `json:"domain"`
+	Password         string              `json:"password,omitempty"`
+	IPv4             int                 `json:"ipv4_number"`
+	Os               int                 `json:"os"`
+	AntiSpoofing     bool                `json:"anti_spoofing"`
+	CpuMode          string              `json:"cpu_mode"`
+	Preset           int                 `json:"preset,omitempty"`
+	IPv4Pools        []int               `json:"ipv4_pools,omitempty"`
+	Recipes          []vmRecipe          `json:"recipe_list,omitempty"`
+	CustomInterfaces []vmCustomInterface `json:"custom_interfaces,omitempty"`
+	Vxlans           []vmVxlan           `json:"vxlan,omitempty"`
+	CloudInit        *vmCloudInit        `json:"cloud_init,omitempty"`
+	SshKeys          []vmSshKey          `json:"ssh_keys,omitempty"`
+	EmailMode        string              `json:"send_email_mode,omitempty"`
+}
+
+type vmReinstallConfig struct {
+	Os        int          `json:"os"`
+	Password  string       `json:"password,omitempty"`
+	Recipes   []vmRecipe   `json:"recipe_list,omitempty"`
+	CloudInit *vmCloudInit `json:"cloud_init,omitempty"`
+	SshKeys   []vmSshKey   `json:"ssh_keys,omitempty"`
+	EmailMode string       `json:"send_email_mode,omitempty"`
+}
+
+func (c *apiClient) CreateVm(config vmCreateConfig) (int, error) {
+	var answer struct {
+		Id int `json:"id"`
+	}
+	err := c.post("/vm/v3/host", config, &answer)
+	if err != nil {
+		return 0, err
+	}
+	return answer.Id, c.waitVm(answer.Id)
+}
+
+func (c *apiClient) ReinstallVm(vmid int, config vmReinstallConfig) error {
+	err := c.post(fmt.Sprintf("/vm/v3/host/%d/reinstall", vmid), config, nil)
+	if err != nil {
+		return err
+	}
+	return c.waitVm(vmid)
+}
+
+// Wait until VM is created or reinstalled
+func (c *apiClient) waitVm(vmid int) error {
+	deadline := time.Now().Add(time.Duration(c.timeout) * time.Second)
+	for {
+		info, err := c.getMap(fmt.Sprintf("/vm/v3/host/%d", vmid))
+		if err != nil {
+			return err
+		}
+		state, _ := info["state"].(string)
+		if strings.HasSuffix(state, "_failed") {
+			return fmt.Errorf("VM %v is in state %s", vmid, state)
+		}
+		if state != "creating" && state != "reinstalling" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for VM %v, it is still in state %s", vmid, state)
+		}
+		time.Sleep(c.pollInterval)
+	}
+}
+
+// One-time VNC session of VM
+func (c *apiClient) GetVmVncSettings(vmid int) (map[string]interface{}, error) {
+	return c.getMap(fmt.Sprintf("/vm/v3/host/%d/vnc_settings", vmid))
+}
+
+// Traffic filtering rule of VM
+type firewallRule struct {
+	Id          int    `json:"id,omitempty"`
+	Interface   int    `json:"interface"`
+	Direction   string `json:"direction"`
+	Protocol    string `json:"protocol"`
+	Ports       string `json:"ports"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Action      string `json:"action"`
+}
+
+func (c *apiClient) GetVmFirewallRules(vmid int) ([]firewallRule, error) {
+	var answer struct {
+		Rules []firewallRule `json:"rules"`
+	}
+	err := c.get(fmt.Sprintf("/vm/v3/host/%d/firewall", vmid), &answer)
+	return answer.Rules, err
+}
+
+// Rules are ordered, so VMmanager gets whole list at once
+func (c *apiClient) SetVmFirewallRules(vmid int, rules []firewallRule) error {
+	return c.post(fmt.Sprintf("/vm/v3/host/%d/firewall", vmid), map[string]interface{}{
+		"rules": rules,
+	}, nil)
+}
+
+// Boot VM into rescue mode and back
+func (c *apiClient) StartRescueMode(vmid int) error {
+	return c.post(fmt.Sprintf("/vm/v3/host/%d/rescue_mode/start", vmid), map[string]interface{}{}, nil)
+}
+
+func (c *apiClient) StopRescueMode(vmid int) error {
+	return c.post(fmt.Sprintf("/vm/v3/host/%d/rescue_mode/stop", vmid), map[string]interface{}{}, nil)
+}
+
+// VM tags are key-value pairs
+func (c *apiClient) GetVmTags(vmid int) (map[string]string, error) {
+	var answer struct {
+		Tags map[string]string `json:"tags"`
+	}
+	err := c.get(fmt.Sprintf("/vm/v3/host/%d/tags", vmid), &answer)
+	return answer.Tags, err
+}
+
+func (c *apiClient) SetVmTags(vmid int, tags map[string]string) error {
+	return c.post(fmt.Sprintf("/vm/v3/host/%d/tags", vmid), map[string]interface{}{
+		"tags": tags,
+	}, nil)
+}
+
+// VxLAN changes in place
+func (c *apiClient) UpdateVxLANSettings(id string, comment string) error {
+	return c.post("/vm/v3/vxlan/"+id, map[string]interface{}{
+		"comment": comment,
+	}, nil)
+}
+
+func (c *apiClient) AddVxLANCluster(id string, cluster int) error {
+	return c.post("/vm/v3/vxlan/"+id+"/cluster", map[string]interface{}{
+		"cluster": cluster,
+	}, nil)
+}
+
+func (c *apiClient) CreateVxLANIpnet(id string, name string, gateway string) error {
+	return c.post("/vm/v3/vxlan/"+id+"/ipnet", map[string]interface{}{
+		"name":    name,
+		"gateway": gateway,
+	}, nil)
+}
+
+func (c *apiClient) DeleteVxLANIpnet(ipnetId int) error {
+	return c.delete(fmt.Sprintf("/vm/v3/vxlan/ipnet/%d", ipnetId))
+}
+
+// Account public ssh key is removed by its id
+func (c *apiClient) AccountDeleteSshKey(accountId string, keyId int) error {
+	return c.delete(fmt.Sprintf("/vm/v3/account/%s/ssh_key/%d", accountId, keyId))
+}
+
+func (c *apiClient) ChangeAccountPassword(accountId string, password string) error {
+	return c.post("/vm/v3/account/"+accountId+"/password", map[string]interface{}{
+		"password": password,
+	}, nil)
+}
+
+// Account limits, 0 means unlimited
+type accountLimits struct {
+	Vms    int `json:"vm_count"`
+	Cores  int `json:"cpu_number"`
+	Memory int `json:"ram_mib"`
+	Disk   int `json:"hdd_mib"`
+	IPv4   int `json:"ipv4_number"`
+	Vxlans int `json:"vxlan_count"`
+}
+
+func (c *apiClient) GetAccountLimits(accountId string) (accountLimits, error) {
+	var limits accountLimits
+	err := c.get("/vm/v3/account/"+accountId+"/limit", &limits)
+	return limits, err
+}
+
+func (c *apiClient) SetAccountLimits(accountId string, limits accountLimits) error {
+	return c.post("/vm/v3/account/"+accountId+"/limit", limits, nil)
+}
+
+// Blocked account can't log in, its VMs are kept.
+// VMmanager shows it in state suspended
+const accountStateSuspended = "suspended"
+
+func (c *apiClient) BlockAccount(accountId string) error {
+	return c.post("/vm/v3/account/"+accountId+"/suspend", map[string]interface{}{}, nil)
+}
+
+func (c *apiClient) UnblockAccount(accountId string) error {
+	return c.post("/vm/v3/account/"+accountId+"/resume", map[string]interface{}{}, nil)
+}
+
+// Account which owns pm_email or pm_api_token
+func (c *apiClient) GetCurrentAccountId() (string, error) {
+	var answer struct {
+		Id int `json:"id"`
+	}
+	err := c.get("/auth/v4/whoami", &answer)
+	if err != nil {
+		return "", err
+	}
+	if answer.Id == 0 {
+		return "", fmt.Errorf("Can't get current account id")
+	}
+	return fmt.Sprint(answer.Id), nil
+}
+
+type projectConfig struct {
+	Name    string `json:"name"`
+	Comment string `json:"comment"`
+}
+
+func (c *apiClient) CreateProject(config projectConfig) (string, error) {
+	return c.create("/vm/v3/project", config)
+}
+
+func (c *apiClient) GetProjectInfo(id string) (projectConfig, error) {
+	var config projectConfig
+	err := c.get("/vm/v3/project/"+id, &config)
+	return config, err
+}
+
+func (c *apiClient) UpdateProject(id string, config projectConfig) error {
+	return c.post("/vm/v3/project/"+id, config, nil)
+}
+
+func (c *apiClient) DeleteProject(id string) error {
+	return c.delete("/vm/v3/project/" + id)
+}
+
+func (c *apiClient) AddProjectMember(projectId string, accountId string) error {
+	return c.post("/vm/v3/project/"+projectId+"/account", map[string]interface{}{
+		"account": json.Number(accountId),
+	}, nil)
+}
+
+func (c *apiClient) GetProjectMembers(projectId string) ([]map[string]interface{}, error) {
+	return c.getList("/vm/v3/project/" + projectId + "/account")
+}
+
+func (c *apiClient) DeleteProjectMember(projectId string, accountId string) error {
+	return c.delete("/vm/v3/project/" + projectId + "/account/" + accountId)
+}
+
+type roleConfig struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"human_name"`
+	Permissions []string `json:"permissions"`
+}
+
+func (c *apiClient) CreateRole(config roleConfig) (string, error) {
+	return c.create("/auth/v4/role", config)
+}
+
+func (c *apiClient) GetRoleInfo(id string) (roleConfig, error) {
+	var config roleConfig
+	err := c.get("/auth/v4/role/"+id, &config)
+	return config, err
+}
+
+// Role name can't be changed
+func (c *apiClient) UpdateRole(id string, config roleConfig) error {
+	config.Name = ""
+	return c.post("/auth/v4/role/"+id, config, nil)
+}
+
+func (c *apiClient) DeleteRole(id string) error {
+	return c.delete("/auth/v4/role/" + id)
+}
+
+// Bind password is write only, API doesn't return it
+type ldapConfig struct {
+	Name           string            `json:"name"`
+	Url            string            `json:"url"`
+	BindDn         string            `json:"bind_dn"`
+	BindPassword   string            `json:"bind_password,omitempty"`
+	SearchBase     string            `json:"search_base"`
+	UserFilter     string            `json:"user_filter"`
+	EmailAttribute string            `json:"email_attribute"`
+	GroupRoles     map[string]string `json:"group_roles"`
+	StartTls       bool              `json:"start_tls"`
+	TlsInsecure    bool              `json:"tls_insecure"`
+	CaCert         string            `json:"ca_cert"`
+}
+
+func (c *apiClient) CreateLdap(config ldapConfig) (string, error) {
+	return c.create("/auth/v4/ldap", config)
+}
+
+func (c *apiClient) GetLdapInfo(id string) (ldapConfig, error) {
+	var config ldapConfig
+	err := c.get("/auth/v4/ldap/"+id, &config)
+	return config, err
+}
+
+func (c *apiClient) UpdateLdap(id string, config ldapConfig) error {
+	return c.post("/auth/v4/ldap/"+id, config, nil)
+}
+
+func (c *apiClient) DeleteLdap(id string) error {
+	return c.delete("/auth/v4/ldap/" + id)
+}
+
+type apiTokenConfig struct {
+	Account     int    `json:"account"`
+	Description string `json:"desc"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+// API shows token only in create answer
+func (c *apiClient) CreateApiToken(config apiTokenConfig) (string, string, error) {
+	var answer struct {
+		Id    int    `json:"id"`
+		Token string `json:"token"`
+	}
+	err := c.post("/auth/v4/token", config, &answer)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprint(answer.Id), answer.Token, nil
+}
+
+func (c *apiClient) GetApiTokenInfo(id string) (map[string]interface{}, error) {
+	return c.getMap("/auth/v4/token/" + id)
+}
+
+func (c *apiClient) DeleteApiToken(id string) error {
+	return c.delete("/auth/v4/token/" + id)
+}
+
+// VMmanager updates gateway, dns, vlan and mtu of network at once
+type networkSettings struct {
+	Gateway    string   `json:"gateway"`
+	DnsServers []string `json:"dns"`
+	Vlan       int      `json:"vlan"`
+	Mtu        int      `json:"mtu"`
+}
+
+func (c *apiClient) UpdateNetwork(id string, settings networkSettings) error {
+	if settings.DnsServers == nil {
+		settings.DnsServers = []string{}
+	}
+	return c.post("/vm/v3/ipnet/"+id, settings, nil)
+}
+
+// Allocated ip address of network or pool, VmId is 0 if ip is not assigned to VM
+type ipAddress struct {
+	Addr string `json:"name"`
+	VmId int    `json:"vm_id"`
+}
+
+func (c *apiClient) getIps(path string) ([]ipAddress, error) {
+	var answer struct {
+		List []ipAddress `json:"list"`
+	}
+	err := c.get(path, &answer)
+	return answer.List, err
+}
+
+func (c *apiClient) GetNetworkIps(id string) ([]ipAddress, error) {
+	return c.getIps("/vm/v3/ipnet/" + id + "/ip")
+}
+
+func (c *apiClient) GetPoolIps(id string) ([]ipAddress, error) {
+	return c.getIps("/vm/v3/pool/" + id + "/ip")
+}
+
+func (c *apiClient) EnablePoolOnCluster(poolId string, cluster int) error {
+	return c.post(fmt.Sprintf("/vm/v3/pool/%s/cluster/%d", poolId, cluster), map[string]interface{}{}, nil)
+}
+
+func (c *apiClient) DisablePoolOnCluster(poolId string, cluster int) error {
+	return c.delete(fmt.Sprintf("/vm/v3/pool/%s/cluster/%d", poolId, cluster))
+}
+
+func (c *apiClient) GetNetworkList() ([]map[string]interface{}, error) {
+	return c.getList("/vm/v3/ipnet")
+}
@@ -0,0 +1,188 @@
+package vmmanager6
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestApiClient(t *testing.T, handler http.HandlerFunc) *apiClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	api, err := newApiClient(server.URL+"/vm/v3", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api.pollInterval = 0
+	return api
+}
+
+func TestApiClientLogin(t *testing.T) {
+	api := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/v4/public/token":
+			var params map[string]string
+			json.NewDecoder(r.Body).Decode(&params)
+			if r.Method != http.MethodPost || params["email"] != "admin@example.com" || params["password"] != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"token": "4-session"}`))
+		case "/vm/v3/os/1":
+			cookie, err := r.Cookie("ses6")
+			if r.Header.Get("x-xsrf-token") != "4-session" || err != nil || cookie.Value != "4-session" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"id": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	if _, err := api.GetOsInfo(1); err == nil {
+		t.Error("request without session expected to fail")
+	}
+	if err := api.login("admin@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if api.token != "4-session" {
+		t.Errorf("token = %q, want %q", api.token, "4-session")
+	}
+	if _, err := api.GetOsInfo(1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApiClientApiToken(t *testing.T) {
+	api := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("ses6")
+		if r.Header.Get("x-xsrf-token") != "api-token" || err != nil || cookie.Value != "api-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": 1}`))
+	})
+	api.setApiToken("api-token")
+	if _, err := api.GetOsInfo(1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestApiClientRequestError(t *testing.T) {
+	api := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vm/v3/os/1":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": 1001, "msg": "Invalid os"}}`))
+		case "/vm/v3/os/2":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`<html>Internal Server Error</html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tests := []struct {
+		id       int
+		status   int
+		msg      string
+		notFound bool
+		err      string
+	}{
+		{id: 1, status: 400, msg: "Invalid os", err: "GET /vm/v3/os/1: Invalid os (code 1001)"},
+		{id: 2, status: 500, err: "GET /vm/v3/os/2: 500 Internal Server Error"},
+		{id: 3, status: 404, notFound: true, err: "GET /vm/v3/os/3: 404 Not Found"},
+	}
+	for _, tt := range tests {
+		_, err := api.GetOsInfo(tt.id)
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			t.Errorf("GetOsInfo(%v) error = %#v, want *apiError", tt.id, err)
+			continue
+		}
+		if apiErr.Status != tt.status || apiErr.Msg != tt.msg || err.Error() != tt.err {
+			t.Errorf("GetOsInfo(%v) error = %+v, want status %v, msg %q, %q", tt.id, apiErr, tt.status, tt.msg, tt.err)
+		}
+		if isNotFound(err) != tt.notFound {
+			t.Errorf("isNotFound(GetOsInfo(%v)) = %v, want %v", tt.id, isNotFound(err), tt.notFound)
+		}
+	}
+}
+
+func TestApiClientWaitVm(t *testing.T) {
+	tests := []struct {
+		states  []string
+		timeout int
+		err     string
+	}{
+		{states: []string{"creating", "creating", "active"}, timeout: 1},
+		{states: []string{"reinstalling", "stopped"}, timeout: 1},
+		{states: []string{"creating", "creation_failed"}, timeout: 1, err: "VM 1 is in state creation_failed"},
+		{states: []string{"creating"}, timeout: 0, err: "timeout waiting for VM 1, it is still in state creating"},
+	}
+	for _, tt := range tests {
+		requests := 0
+		api := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/vm/v3/host/1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			// last state stays forever
+			state := tt.states[len(tt.states)-1]
+			if requests < len(tt.states) {
+				state = tt.states[requests]
+			}
+			requests++
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": 1, "state": state})
+		})
+		api.timeout = tt.timeout
+		err := api.waitVm(1)
+		if tt.err == "" && err != nil {
+			t.Errorf("waitVm with states %v: unexpected error %v", tt.states, err)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("waitVm with states %v: error = %v, want %q", tt.states, err, tt.err)
+		}
+		if tt.err == "" && requests != len(tt.states) {
+			t.Errorf("waitVm with states %v: %v requests, want %v", tt.states, requests, len(tt.states))
+		}
+	}
+}
+
+func TestApiClientCreate(t *testing.T) {
+	api := newTestApiClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]string
+		json.NewDecoder(r.Body).Decode(&params)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch params["name"] {
+		case "project":
+			w.Write([]byte(`{"id": 12}`))
+		case "exists":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"error": {"code": 2002, "msg": "Project already exists"}}`))
+		default:
+			w.Write([]byte(`not json`))
+		}
+	})
+	tests := []struct {
+		name string
+		id   string
+		err  string
+	}{
+		{name: "project", id: "12"},
+		{name: "exists", err: "POST /vm/v3/project: Project already exists (code 2002)"},
+		{name: "broken", err: "invalid character 'o' in literal null (expecting 'u')"},
+	}
+	for _, tt := range tests {
+		id, err := api.create("/vm/v3/project", map[string]string{"name": tt.name})
+		if tt.err == "" && (err != nil || id != tt.id) {
+			t.Errorf("create(%q) = %q, %v, want %q", tt.name, id, err, tt.id)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("create(%q) error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package vmmanager6
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	vm6api "github.com/usaafko/vmmanager6-api-go"
+)
+
+func dataSourceAccountSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"email": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "User log in to VMmanager by email",
+		},
+		"role": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "User role",
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "User state",
+		},
+		"ssh_keys": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Public ssh keys of account",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"id": {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "id of public ssh key",
+					},
+					"name": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "name of public ssh key",
+					},
+					"ssh_pub_key": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "public ssh key",
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAccount() *schema.Resource {
+	accountSchema := dataSourceAccountSchema()
+	accountSchema["email"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "User log in to VMmanager by email",
+	}
+	return &schema.Resource{
+		Read:   dataSourceAccountRead,
+		Schema: accountSchema,
+	}
+}
+
+func dataSourceCurrentAccount() *schema.Resource {
+	return &schema.Resource{
+		Read:   dataSourceCurrentAccountRead,
+		Schema: dataSourceAccountSchema(),
+	}
+}
+
+func dataSourceAccountRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+
+	email := d.Get("email").(string)
+	id, err := client.GetAccountIdByEmail(email)
+	if err != nil {
+		return err
+	}
+	if id == "0" {
+		return fmt.Errorf("Can't find account with email %v", email)
+	}
+	return _dataSourceAccountRead(d, client, id)
+}
+
+func dataSourceCurrentAccountRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+
+	// account of pm_email or pm_api_token
+	id, err := pconf.Api.GetCurrentAccountId()
+	if err != nil {
+		return err
+	}
+	return _dataSourceAccountRead(d, client, id)
+}
+
+func _dataSourceAccountRead(d *schema.ResourceData, client *vm6api.Client, id string) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("datasource_account_read")
+
+	info, err := client.GetAccountInfo(id)
+	if err != nil {
+		return err
+	}
+	config, err := vm6api.NewConfigAccountFromApi(id, client)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Msgf("[READ] Received Account Config from VMmanager6 API: %+v", config)
+
+	d.SetId(id)
+	d.Set("email", info["email"])
+	d.Set("role", config.Role)
+	d.Set("state", config.State)
+
+	ssh_keys, err := client.AccountGetSshKeys(id)
+	if err != nil {
+		return err
+	}
+	d.Set("ssh_keys", ssh_keys)
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package vmmanager6
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	vm6api "github.com/usaafko/vmmanager6-api-go"
+)
+
+func dataSourceIpUsageSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"total": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "How many ip addresses there are, large IPv6 networks are capped at 2147483647",
+		},
+		"used": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "How many ip addresses are allocated",
+		},
+		"free": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "How many ip addresses are free",
+		},
+		"addresses": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Allocated ip addresses",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"ip": {
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "Ip address",
+					},
+					"vm_id": {
+						Type:        schema.TypeInt,
+						Computed:    true,
+						Description: "id of VM which uses ip address, 0 if ip is not assigned to VM",
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNetworkUsage() *schema.Resource {
+	usageSchema := dataSourceIpUsageSchema()
+	usageSchema["network_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "id of network",
+	}
+	usageSchema["total"].Description = "How many ip addresses of network can be assigned. Gateway and, for IPv4 networks larger than /31, network and broadcast addresses are not counted. Large IPv6 networks are capped at 2147483647"
+	return &schema.Resource{
+		Read:   dataSourceNetworkUsageRead,
+		Schema: usageSchema,
+	}
+}
+
+func dataSourcePoolUsage() *schema.Resource {
+	usageSchema := dataSourceIpUsageSchema()
+	usageSchema["pool_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "id of pool",
+	}
+	return &schema.Resource{
+		Read:   dataSourcePoolUsageRead,
+		Schema: usageSchema,
+	}
+}
+
+func dataSourceNetworkUsageRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+	// create a logger for this function
+	logger, _ := CreateSubLogger("datasource_network_usage_read")
+
+	networkID := d.Get("network_id").(string)
+	_, err := client.GetNetworkInfo(networkID)
+	if err != nil {
+		return fmt.Errorf("Can't find network %v: %v", networkID, err)
+	}
+	config, err := vm6api.NewConfigNetworkFromApi(networkID, client)
+	if err != nil {
+		return err
+	}
+	total, err := networkAssignableSize(config.Name, config.Gateway)
+	if err != nil {
+		return err
+	}
+	ips, err := pconf.Api.GetNetworkIps(networkID)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Str("network", config.Name).Int("total", total).Int("used", len(ips)).Msg("Received network usage from VMmanager6 API")
+
+	d.SetId(networkID)
+	setIpUsage(d, total, ips)
+	return nil
+}
+
+func dataSourcePoolUsageRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+	// create a logger for this function
+	logger, _ := CreateSubLogger("datasource_pool_usage_read")
+
+	poolID := d.Get("pool_id").(string)
+	config, err := client.GetPoolInfo(poolID)
+	if err != nil {
+		return fmt.Errorf("Can't find pool %v: %v", poolID, err)
+	}
+	// pool size is sum of all its ranges
+	total := 0
+	ipnets, _ := config["ipnets"].([]interface{})
+	for _, v := range ipnets {
+		size, err := ipRangeSize(v.(map[string]interface{})["name"].(string))
+		if err != nil {
+			return err
+		}
+		total += size
+		if total > math.MaxInt32 {
+			total = math.MaxInt32
+		}
+	}
+	ips, err := pconf.Api.GetPoolIps(poolID)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Str("pool", poolID).Int("total", total).Int("used", len(ips)).Msg("Received pool usage from VMmanager6 API")
+
+	d.SetId(poolID)
+	setIpUsage(d, total, ips)
+	return nil
+}
+
+func setIpUsage(d *schema.ResourceData, total int, ips []ipAddress) {
+	var addresses []map[string]interface{}
+	for _, ip := range ips {
+		addresses = append(addresses, map[string]interface{}{
+			"ip":    ip.Addr,
+			"vm_id": ip.VmId,
+		})
+	}
+	free := total - len(ips)
+	if free < 0 {
+		free = 0
+	}
+	d.Set("total", total)
+	d.Set("used", len(ips))
+	d.Set("free", free)
+	d.Set("addresses", addresses)
+}
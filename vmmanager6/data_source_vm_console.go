@@ -0,0 +1,76 @@
+package vmmanager6
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	vm6api "github.com/usaafko/vmmanager6-api-go"
+)
+
+func dataSourceVmConsole() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceVmConsoleRead,
+		Schema: map[string]*schema.Schema{
+			"vm_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "id of VM",
+			},
+			"url": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "noVNC url to open VM console in browser",
+			},
+			"host": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "VNC host",
+			},
+			"port": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "VNC port",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "One-time VNC password",
+			},
+		},
+	}
+}
+
+func dataSourceVmConsoleRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+	// create a logger for this function
+	logger, _ := CreateSubLogger("datasource_vm_console_read")
+
+	vmID := d.Get("vm_id").(int)
+	vmr := vm6api.NewVmRef(vmID)
+
+	_, err := client.GetVmInfo(vmr)
+	if err != nil {
+		return fmt.Errorf("Can't find VM %v: %v", vmID, err)
+	}
+
+	// VMmanager creates new VNC session on every request
+	vnc, err := pconf.Api.GetVmVncSettings(vmID)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Int("vmid", vmID).Msg("Received VNC session from VMmanager6 API")
+
+	d.SetId(strconv.Itoa(vmID))
+	d.Set("url", vnc["url"])
+	d.Set("host", vnc["host"])
+	d.Set("port", mapInt(vnc, "port"))
+	d.Set("password", vnc["password"])
+
+	return nil
+}
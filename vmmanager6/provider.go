@@ -7,11 +7,13 @@ import (
 	"sync"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	vm6api "github.com/usaafko/vmmanager6-api-go"
 )
 
 type providerConfiguration struct {
 	Client                             *vm6api.Client
+	Api                                *apiClient
 	MaxParallel                        int
 	CurrentParallel                    int
 	MaxVMID                            int
@@ -20,6 +22,8 @@ type providerConfiguration struct {
 	LogFile                            string
 	LogLevels                          map[string]string
 	DangerouslyIgnoreUnknownAttributes bool
+	EmailMode                          string
+	DefaultTags                        map[string]string
 }
 
 // Provider - Terrafrom properties for vmmanager6
@@ -93,6 +97,21 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("PM_DANGEROUSLY_IGNORE_UNKNOWN_ATTRIBUTES", false),
 				Description: "By default this provider will exit if an unknown attribute is found. This is to prevent the accidential destruction of VMs or Data when something in the VMmanager 6 API has changed/updated and is not confirmed to work with this provider. Set this to true at your own risk. It may allow you to proceed in cases when the provider refuses to work, but be aware of the danger in doing so.",
 			},
+			"pm_email_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("PM_EMAIL_MODE", ""),
+				Description:  "Default email notification mode for VM creation and OS reinstall. Can be none, owner, admin. When not set, VMmanager default notifications are sent on VM creation and none on OS reinstall",
+				ValidateFunc: validation.StringInSlice(emailModes, false),
+			},
+			"pm_default_tags": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Tags, that will be added to every VM",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
 			"pm_debug": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -101,21 +120,35 @@ func Provider() *schema.Provider {
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"vmmanager6_vm_qemu": resourceVmQemu(),
-			"vmmanager6_network": resourceNetwork(),
-			"vmmanager6_pool":    resourcePool(),
-			"vmmanager6_account": resourceAccount(),
-			"vmmanager6_vxlan":   resourceVxlan(),
+			"vmmanager6_vm_qemu":        resourceVmQemu(),
+			"vmmanager6_network":        resourceNetwork(),
+			"vmmanager6_pool":           resourcePool(),
+			"vmmanager6_account":        resourceAccount(),
+			"vmmanager6_vxlan":          resourceVxlan(),
+			"vmmanager6_firewall":       resourceFirewall(),
+			"vmmanager6_ssh_key":        resourceSshKey(),
+			"vmmanager6_project":        resourceProject(),
+			"vmmanager6_project_member": resourceProjectMember(),
+			"vmmanager6_role":           resourceRole(),
+			"vmmanager6_ldap":           resourceLdap(),
+			"vmmanager6_api_token":      resourceApiToken(),
 			//        "vmmanager6_lxc":      resourceLxc(),
 			//        "vmmanager6_lxc_disk": resourceLxcDisk(),
 			//        "vmmanager6_pool":     resourcePool(),
 		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"vmmanager6_vm_console":      dataSourceVmConsole(),
+			"vmmanager6_account":         dataSourceAccount(),
+			"vmmanager6_current_account": dataSourceCurrentAccount(),
+			"vmmanager6_network_usage":   dataSourceNetworkUsage(),
+			"vmmanager6_pool_usage":      dataSourcePoolUsage(),
+		},
 
 		ConfigureFunc: providerConfigure,
 	}
 }
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	client, err := getClient(
+	client, api, err := getClient(
 		d.Get("pm_api_url").(string),
 		d.Get("pm_email").(string),
 		d.Get("pm_password").(string),
@@ -147,9 +180,15 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		logLevels,
 	)
 
+	defaultTags := make(map[string]string)
+	for k, v := range d.Get("pm_default_tags").(map[string]interface{}) {
+		defaultTags[k] = v.(string)
+	}
+
 	var mut sync.Mutex
 	return &providerConfiguration{
 		Client:                             client,
+		Api:                                api,
 		MaxParallel:                        d.Get("pm_parallel").(int),
 		CurrentParallel:                    0,
 		MaxVMID:                            -1,
@@ -158,6 +197,8 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		LogFile:                            d.Get("pm_log_file").(string),
 		LogLevels:                          logLevels,
 		DangerouslyIgnoreUnknownAttributes: d.Get("pm_dangerously_ignore_unknown_attributes").(bool),
+		EmailMode:                          d.Get("pm_email_mode").(string),
+		DefaultTags:                        defaultTags,
 	}, nil
 }
 func getClient(pm_api_url string,
@@ -166,7 +207,7 @@ func getClient(pm_api_url string,
 	pm_api_token string,
 	pm_tls_insecure bool,
 	pm_timeout int,
-	pm_debug bool) (*vm6api.Client, error) {
+	pm_debug bool) (*vm6api.Client, *apiClient, error) {
 
 	tlsconf := &tls.Config{InsecureSkipVerify: true}
 	if !pm_tls_insecure {
@@ -184,21 +225,29 @@ func getClient(pm_api_url string,
 
 	client, _ := vm6api.NewClient(pm_api_url, nil, tlsconf, pm_timeout)
 	*vm6api.Debug = pm_debug
+	api, apiErr := newApiClient(pm_api_url, tlsconf, pm_timeout)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
 
-	// User+Pass authentication
+	// User+Pass authentication, both clients use the same session
 	if pm_email != "" && pm_password != "" {
-		err = client.Login(pm_email, pm_password)
+		err = api.login(pm_email, pm_password)
+		if err == nil {
+			client.SetAPIToken(api.token)
+		}
 	}
 
 	// API authentication
 	if pm_api_token != "" {
 		client.SetAPIToken(pm_api_token)
+		api.setApiToken(pm_api_token)
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return client, nil
+	return client, api, nil
 }
 
 type pmApiLockHolder struct {
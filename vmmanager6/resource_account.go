@@ -6,8 +6,8 @@ import (
 	"strings"
 
 	//	"strconv"
-	//	"fmt"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -42,27 +42,99 @@ func resourceAccount() *schema.Resource {
 				Description: "Internal - user state",
 			},
 			"role": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "User role, e.g. @admin or @advanced_user or @user, or name of custom role (vmmanager6_role)",
+				Default:      "@admin",
+				ValidateFunc: validation.StringMatch(rxRoleName, "must be @admin, @advanced_user, @user or custom role name"),
+			},
+			"password": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				Description:      "User password. Changed in place. Required, if generate_password is not set",
+				DiffSuppressFunc: passwordDiffSuppress,
+			},
+			"password_write_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Don't save password to state, only its salted PBKDF2 hash. Password changes are still detected",
+			},
+			"generate_password": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Generate random password for user, if password is not set",
+			},
+			"password_rotation_trigger": {
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: "User role, must be @admin or @advanced_user or @user",
-				Default:     "@admin",
-				ValidateFunc: validation.StringInSlice([]string{
-					"@admin",
-					"@advanced_user",
-					"@user",
-				}, false),
+				Description: "Any change of this value generates new password, when generate_password is set",
 			},
-			"password": {
+			"generated_password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Computed:    true,
 				Sensitive:   true,
-				Description: "User password",
-				ForceNew:    true,
+				Description: "Password generated with generate_password",
 			},
-			"ssh_keys": {
+			"enabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "User can log in. Set to false to block user",
+			},
+			"limits": {
 				Type:        schema.TypeList,
 				Optional:    true,
-				Description: "Set of public ssh keys for account",
+				MaxItems:    1,
+				Description: "User limits. 0 means unlimited. Limits are read back from VMmanager only when this block is set",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"vms": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max number of VMs",
+						},
+						"cores": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max number of vCPU's for all VMs",
+						},
+						"memory": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max RAM Size for all VMs in Megabytes",
+						},
+						"disk": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max Disk Size for all VMs in Megabytes",
+						},
+						"ipv4_number": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max number of ipv4 addresses",
+						},
+						"vxlans": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "Max number of VxLANs",
+						},
+					},
+				},
+			},
+			"ssh_keys": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Set of public ssh keys for account. Renaming a key deletes and adds it again, so its id changes and VMs must refer to the new id in ssh_key_ids",
+				Set:         accountSshKeyHash,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"id": {
@@ -86,6 +158,12 @@ func resourceAccount() *schema.Resource {
 					},
 				},
 			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Manage existing account with the same email on create, instead of failing. Without it existing account must be imported",
+			},
 		},
 	}
 	return accountResource
@@ -112,26 +190,42 @@ func resourceAccountCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	if vmid != "0" {
 		//Account already exists
+		if !d.Get("adopt_existing").(bool) {
+			return adoptExistingError("account", d.Get("email").(string), vmid)
+		}
 		logger.Debug().Msgf("Account already exists id %v", vmid)
 		d.SetId(vmid)
 		_resourceAccountRead(d, meta)
 		return nil
 	}
 
+	password := configPassword(d)
+	if password == "" {
+		if !d.Get("generate_password").(bool) {
+			return fmt.Errorf("password is required, when generate_password is not set")
+		}
+		password, err = generatePassword(16)
+		if err != nil {
+			return err
+		}
+		d.Set("generated_password", password)
+	}
+
 	config := vm6api.ConfigNewAccount{
 		Email:    d.Get("email").(string),
 		Role:     d.Get("role").(string),
-		Password: d.Get("password").(string),
+		Password: password,
 	}
 	vmid, err = config.CreateAccount(client)
 	if err != nil {
 		return err
 	}
 	d.SetId(vmid)
+	passwordToState(d)
 	logger.Debug().Msgf("Finished account read resulting in data: '%+v'", string(jsonString))
 
 	// Collect ssh keys from config
-	ssh_keys_config := d.Get("ssh_keys").([]interface{})
+	ssh_keys_config := d.Get("ssh_keys").(*schema.Set).List()
 	var ssh_keys_api []vm6api.SshKeyConfig
 
 	j, err := json.Marshal(ssh_keys_config)
@@ -155,6 +249,19 @@ func resourceAccountCreate(d *schema.ResourceData, meta interface{}) error {
 		d.Set("ssh_keys", ssh_keys)
 	}
 
+	if limits := accountLimitsFromConfig(d); limits != (accountLimits{}) {
+		err = pconf.Api.SetAccountLimits(vmid, limits)
+		if err != nil {
+			return err
+		}
+	}
+	if !d.Get("enabled").(bool) {
+		err = pconf.Api.BlockAccount(vmid)
+		if err != nil {
+			return err
+		}
+	}
+
 	log.Print("[DEBUG][AccountCreate] creation done!")
 	return nil
 }
@@ -181,16 +288,169 @@ func resourceAccountUpdate(ctx context.Context, d *schema.ResourceData, meta int
 		if err != nil {
 			return diag.FromErr(err)
 		}
+	}
+	if d.HasChange("enabled") {
+		if d.Get("enabled").(bool) {
+			logger.Info().Msg("Unblock account")
+			err = pconf.Api.UnblockAccount(d.Id())
+		} else {
+			logger.Info().Msg("Block account")
+			err = pconf.Api.BlockAccount(d.Id())
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChange("limits") {
+		limits := accountLimitsFromConfig(d)
+		logger.Info().Msgf("Change account limits %+v", limits)
+		err = pconf.Api.SetAccountLimits(d.Id(), limits)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 
 	}
+	if password := configPassword(d); d.HasChange("password") && password != "" {
+		logger.Info().Msg("Change account password")
+		err = pconf.Api.ChangeAccountPassword(d.Id(), password)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChanges("password", "password_write_only") {
+		passwordToState(d)
+	}
+	if configPassword(d) == "" && d.Get("generate_password").(bool) &&
+		(d.HasChange("password_rotation_trigger") || d.Get("generated_password").(string) == "") {
+		logger.Info().Msg("Rotate generated account password")
+		password, err := generatePassword(16)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = pconf.Api.ChangeAccountPassword(d.Id(), password)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("generated_password", password)
+	}
 	if d.HasChange("ssh_keys") {
-		// TODO: add ssh change
+		oldValuesRaw, newValuesRaw := d.GetChange("ssh_keys")
+		oldValues := oldValuesRaw.(*schema.Set)
+		newValues := newValuesRaw.(*schema.Set)
+		// Keys are compared by public key. New keys are added before old ones
+		// are deleted, so account is not left without keys in case of error.
+		// Renamed key is deleted and added again, so it gets new id
+		var renamed []map[string]interface{}
+		for _, v := range newValues.List() {
+			newKey := v.(map[string]interface{})
+			oldKey, ok := findSshKey(oldValues, newKey)
+			if ok && newKey["name"] == oldKey["name"] {
+				continue
+			}
+			if ok {
+				renamed = append(renamed, oldKey)
+				continue
+			}
+			err = accountAddSshKey(d, client, newKey)
+			if err != nil {
+				return accountSshKeysUpdateFailed(d, client, err)
+			}
+		}
+		for _, oldKey := range renamed {
+			newKey, _ := findSshKey(newValues, oldKey)
+			logger.Debug().Msgf("rename key %v to %v", oldKey["name"], newKey["name"])
+			err = pconf.Api.AccountDeleteSshKey(d.Id(), oldKey["id"].(int))
+			if err != nil {
+				return accountSshKeysUpdateFailed(d, client, err)
+			}
+			err = accountAddSshKey(d, client, newKey)
+			if err != nil {
+				return accountSshKeysUpdateFailed(d, client, err)
+			}
+		}
+		for _, v := range oldValues.List() {
+			oldKey := v.(map[string]interface{})
+			if _, ok := findSshKey(newValues, oldKey); ok {
+				continue
+			}
+			logger.Debug().Msgf("delete key %v", oldKey["name"])
+			err = pconf.Api.AccountDeleteSshKey(d.Id(), oldKey["id"].(int))
+			if err != nil {
+				return accountSshKeysUpdateFailed(d, client, err)
+			}
+		}
+		// Get ids of new keys
+		ssh_keys, err := client.AccountGetSshKeys(d.Id())
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("ssh_keys", ssh_keys)
 	}
 
 	logger.Info().Msg("End of update of the account resource")
 	return nil
 }
 
+func accountAddSshKey(d *schema.ResourceData, client *vm6api.Client, sshKey map[string]interface{}) error {
+	logger, _ := CreateSubLogger("resource_account_update")
+	var key vm6api.SshKeyConfig
+	j, err := json.Marshal(sshKey)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(j, &key)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Msgf("adding key %#v", key)
+	return client.AccountAddSshKey(d.Id(), key)
+}
+
+// Keys can be changed partially, read them back so state shows what was really done
+func accountSshKeysUpdateFailed(d *schema.ResourceData, client *vm6api.Client, err error) diag.Diagnostics {
+	logger, _ := CreateSubLogger("resource_account_update")
+	logger.Error().Msgf("Can't update account ssh keys %v", err)
+	if sshKeys, readErr := client.AccountGetSshKeys(d.Id()); readErr == nil {
+		d.Set("ssh_keys", sshKeys)
+	}
+	return diag.FromErr(err)
+}
+
+func accountLimitsFromConfig(d *schema.ResourceData) accountLimits {
+	var limits accountLimits
+	for _, v := range d.Get("limits").([]interface{}) {
+		if v == nil {
+			continue
+		}
+		limit := v.(map[string]interface{})
+		limits = accountLimits{
+			Vms:    limit["vms"].(int),
+			Cores:  limit["cores"].(int),
+			Memory: limit["memory"].(int),
+			Disk:   limit["disk"].(int),
+			IPv4:   limit["ipv4_number"].(int),
+			Vxlans: limit["vxlans"].(int),
+		}
+	}
+	return limits
+}
+
+// Ssh keys are identified by public key, so reordering or new ids don't make diff
+func accountSshKeyHash(v interface{}) int {
+	key := v.(map[string]interface{})
+	return schema.HashString(strings.TrimSpace(key["ssh_pub_key"].(string)))
+}
+
+func findSshKey(keys *schema.Set, key map[string]interface{}) (map[string]interface{}, bool) {
+	hash := accountSshKeyHash(key)
+	for _, v := range keys.List() {
+		if accountSshKeyHash(v) == hash {
+			return v.(map[string]interface{}), true
+		}
+	}
+	return nil, false
+}
+
 func resourceAccountRead(d *schema.ResourceData, meta interface{}) error {
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
@@ -230,6 +490,27 @@ func _resourceAccountRead(d *schema.ResourceData, meta interface{}) error {
 
 	d.Set("state", config.State)
 	d.Set("role", config.Role)
+	// blocked account is suspended in VMmanager
+	d.Set("enabled", config.State != accountStateSuspended)
+
+	// limits are read only when they are used, so accounts without limits don't depend on limits API.
+	// All-zero block is kept in state, otherwise it would show a diff forever
+	if len(d.Get("limits").([]interface{})) > 0 {
+		limits, err := pconf.Api.GetAccountLimits(d.Id())
+		if err != nil {
+			return err
+		}
+		d.Set("limits", []map[string]interface{}{
+			{
+				"vms":         limits.Vms,
+				"cores":       limits.Cores,
+				"memory":      limits.Memory,
+				"disk":        limits.Disk,
+				"ipv4_number": limits.IPv4,
+				"vxlans":      limits.Vxlans,
+			},
+		})
+	}
 
 	// Get ssh keys
 	ssh_keys, err := client.AccountGetSshKeys(d.Id())
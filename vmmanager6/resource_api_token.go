@@ -0,0 +1,100 @@
+package vmmanager6
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceApiToken() *schema.Resource {
+	// token can't be read from API, so there is no import and update
+	return &schema.Resource{
+		Create: resourceApiTokenCreate,
+		Read:   resourceApiTokenRead,
+		Delete: resourceApiTokenDelete,
+		Schema: map[string]*schema.Schema{
+			"account": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "VMmanager user id, token is created for",
+			},
+			"desc": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "",
+				Description: "Token Description",
+			},
+			"expires_at": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Token expiration time in RFC3339 format, e.g. 2030-01-01T00:00:00Z. Token doesn't expire if not set",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"token": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "API token, can be used as pm_api_token",
+			},
+		},
+	}
+}
+
+func resourceApiTokenCreate(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+
+	account := strconv.Itoa(d.Get("account").(int))
+	_, err := client.GetAccountInfo(account)
+	if err != nil {
+		return fmt.Errorf("Can't find account %v: %v", account, err)
+	}
+
+	config := apiTokenConfig{
+		Account:     d.Get("account").(int),
+		Description: d.Get("desc").(string),
+		ExpiresAt:   d.Get("expires_at").(string),
+	}
+	vmid, token, err := pconf.Api.CreateApiToken(config)
+	if err != nil {
+		return err
+	}
+	d.SetId(vmid)
+	// API shows token only once
+	d.Set("token", token)
+
+	log.Print("[DEBUG][ApiTokenCreate] creation done!")
+	return nil
+}
+
+func resourceApiTokenRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	// revoked or expired token is gone
+	_, err := pconf.Api.GetApiTokenInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+	return nil
+}
+
+func resourceApiTokenDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	err := pconf.Api.DeleteApiToken(d.Id())
+	return err
+
+}
@@ -0,0 +1,296 @@
+package vmmanager6
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	vm6api "github.com/usaafko/vmmanager6-api-go"
+)
+
+// using a global variable here so that we have an internally accessible
+// way to look into our own resource definition. Useful for dynamically doing typecasts
+// so that we can print (debug) our ResourceData constructs
+var firewallResource *schema.Resource
+
+func resourceFirewall() *schema.Resource {
+	firewallResource = &schema.Resource{
+		Create:        resourceFirewallCreate,
+		Read:          resourceFirewallRead,
+		UpdateContext: resourceFirewallUpdate,
+		Delete:        resourceFirewallDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"vm": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "id of VM",
+			},
+			"rule": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Ordered list of traffic filtering rules. First matched rule is applied",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "id of rule",
+						},
+						"interface": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Default:     0,
+							Description: "id of VM interface. 0 means all interfaces",
+						},
+						"direction": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Traffic direction, must be in or out",
+							ValidateFunc: validation.StringInSlice([]string{
+								"in",
+								"out",
+							}, false),
+						},
+						"protocol": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "all",
+							Description: "Protocol, must be all, tcp, udp or icmp",
+							ValidateFunc: validation.StringInSlice([]string{
+								"all",
+								"tcp",
+								"udp",
+								"icmp",
+							}, false),
+						},
+						"ports": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							Description:  "Port or port range, e.g. 22 or 1000-2000. Empty means all ports",
+							ValidateFunc: validation.Any(validation.StringIsEmpty, validatePortRange),
+						},
+						"source": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							Description:  "Source network in CIDR format. Empty means any",
+							ValidateFunc: validation.Any(validation.StringIsEmpty, validation.IsCIDR),
+						},
+						"destination": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "",
+							Description:  "Destination network in CIDR format. Empty means any",
+							ValidateFunc: validation.Any(validation.StringIsEmpty, validation.IsCIDR),
+						},
+						"action": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "What to do with traffic, must be accept or drop",
+							ValidateFunc: validation.StringInSlice([]string{
+								"accept",
+								"drop",
+							}, false),
+						},
+					},
+				},
+			},
+		},
+	}
+	return firewallResource
+}
+
+func resourceFirewallCreate(d *schema.ResourceData, meta interface{}) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_firewall_create")
+
+	// DEBUG print out the create request
+	flatValue, _ := resourceDataToFlatValues(d, firewallResource)
+	jsonString, _ := json.Marshal(flatValue)
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+
+	vmID := d.Get("vm").(int)
+	vmr := vm6api.NewVmRef(vmID)
+	_, err := client.GetVmInfo(vmr)
+	if err != nil {
+		return fmt.Errorf("Can't find VM %v: %v", vmID, err)
+	}
+
+	// Existing rules must be imported, so they are not silently overwritten
+	rules, err := pconf.Api.GetVmFirewallRules(vmID)
+	if err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		return fmt.Errorf("VM %v already has %v firewall rules. Import them with terraform import vmmanager6_firewall.<name> %v", vmID, len(rules), vmID)
+	}
+
+	err = pconf.Api.SetVmFirewallRules(vmID, firewallRulesFromConfig(d))
+	if err != nil {
+		return err
+	}
+	d.SetId(strconv.Itoa(vmID))
+	logger.Debug().Int("vmid", vmID).Msgf("Finished firewall create resulting in data: '%+v'", string(jsonString))
+
+	err = _resourceFirewallRead(d, meta)
+	if err != nil {
+		return err
+	}
+
+	log.Print("[DEBUG][FirewallCreate] creation done!")
+	return nil
+}
+
+func resourceFirewallUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_firewall_update")
+
+	logger.Info().Msg("Starting update of the firewall resource")
+
+	vmID := d.Get("vm").(int)
+	if d.HasChange("rule") {
+		// Rules are ordered, so VMmanager gets whole new list at once
+		rules := firewallRulesFromConfig(d)
+		logger.Debug().Msgf("Updating firewall rules %+v", rules)
+		err := pconf.Api.SetVmFirewallRules(vmID, rules)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	err := _resourceFirewallRead(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	logger.Info().Msg("End of update of the firewall resource")
+	return nil
+}
+
+func resourceFirewallRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	return _resourceFirewallRead(d, meta)
+}
+
+func resourceFirewallDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	err := pconf.Api.SetVmFirewallRules(d.Get("vm").(int), []firewallRule{})
+	return err
+
+}
+
+func _resourceFirewallRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	client := pconf.Client
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_firewall_read")
+
+	// resource id is VM id, so import works with it
+	vmID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return err
+	}
+	vmr := vm6api.NewVmRef(vmID)
+
+	_, err = client.GetVmInfo(vmr)
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+	rules, err := pconf.Api.GetVmFirewallRules(vmID)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug().Int("vmid", vmID).Msgf("[READ] Received firewall rules from VMmanager6 API: %+v", rules)
+
+	d.Set("vm", vmID)
+	flatRules := make([]map[string]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		flatRules = append(flatRules, map[string]interface{}{
+			"id":          rule.Id,
+			"interface":   rule.Interface,
+			"direction":   rule.Direction,
+			"protocol":    rule.Protocol,
+			"ports":       rule.Ports,
+			"source":      rule.Source,
+			"destination": rule.Destination,
+			"action":      rule.Action,
+		})
+	}
+	if err = d.Set("rule", flatRules); err != nil {
+		return err
+	}
+
+	// DEBUG print out the read result
+	flatValue, _ := resourceDataToFlatValues(d, firewallResource)
+	jsonString, _ := json.Marshal(flatValue)
+	logger.Debug().Int("vmid", vmID).Msgf("Finished firewall read resulting in data: '%+v'", string(jsonString))
+
+	return nil
+}
+
+// Port is 1-65535, range must start before its end
+func validatePortRange(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	parts := strings.Split(v, "-")
+	if len(parts) > 2 {
+		return nil, []error{fmt.Errorf("%s: %q must be port or port range, e.g. 22 or 1000-2000", k, v)}
+	}
+	var ports []int
+	for _, part := range parts {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, []error{fmt.Errorf("%s: %q must be port or port range, e.g. 22 or 1000-2000. Ports are 1-65535", k, v)}
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 2 && ports[0] > ports[1] {
+		return nil, []error{fmt.Errorf("%s: port range %q starts after it ends", k, v)}
+	}
+	return nil, nil
+}
+
+func firewallRulesFromConfig(d *schema.ResourceData) []firewallRule {
+	rules := []firewallRule{}
+	for _, raw := range d.Get("rule").([]interface{}) {
+		rule := raw.(map[string]interface{})
+		rules = append(rules, firewallRule{
+			Interface:   rule["interface"].(int),
+			Direction:   rule["direction"].(string),
+			Protocol:    rule["protocol"].(string),
+			Ports:       rule["ports"].(string),
+			Source:      rule["source"].(string),
+			Destination: rule["destination"].(string),
+			Action:      rule["action"].(string),
+		})
+	}
+	return rules
+}
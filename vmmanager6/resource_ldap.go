@@ -0,0 +1,216 @@
+package vmmanager6
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// using a global variable here so that we have an internally accessible
+// way to look into our own resource definition. Useful for dynamically doing typecasts
+// so that we can print (debug) our ResourceData constructs
+var ldapResource *schema.Resource
+
+func resourceLdap() *schema.Resource {
+	ldapResource = &schema.Resource{
+		Create:        resourceLdapCreate,
+		Read:          resourceLdapRead,
+		UpdateContext: resourceLdapUpdate,
+		Delete:        resourceLdapDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of LDAP integration",
+			},
+			"server_url": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "LDAP server url, e.g. ldaps://ldap.example.com:636",
+				ValidateFunc: validation.IsURLWithScheme([]string{"ldap", "ldaps"}),
+			},
+			"bind_dn": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "DN of user to bind to LDAP server",
+			},
+			"bind_password": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Password of bind_dn user",
+			},
+			"search_base": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "DN where to search users",
+			},
+			"user_filter": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "(objectClass=person)",
+				Description: "LDAP filter for users",
+			},
+			"email_attribute": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "mail",
+				Description: "LDAP attribute with user email, that is used as VMmanager login",
+			},
+			"group_roles": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Map of LDAP group DN to VMmanager role, e.g. @admin or custom role",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringMatch(rxRoleName, "must be @admin, @advanced_user, @user or custom role name"),
+				},
+			},
+			"start_tls": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Use StartTLS with ldap:// url",
+			},
+			"tls_insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Don't verify LDAP server certificate",
+			},
+			"ca_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "CA certificate in PEM format to verify LDAP server certificate",
+			},
+		},
+	}
+	return ldapResource
+}
+
+func resourceLdapCreate(d *schema.ResourceData, meta interface{}) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_ldap_create")
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	config := ldapConfigFromResource(d)
+	vmid, err := pconf.Api.CreateLdap(config)
+	if err != nil {
+		return err
+	}
+	d.SetId(vmid)
+	logger.Debug().Msgf("Created LDAP integration %v", vmid)
+
+	log.Print("[DEBUG][LdapCreate] creation done!")
+	return nil
+}
+
+func resourceLdapUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_ldap_update")
+
+	logger.Info().Msg("Starting update of the LDAP resource")
+
+	_, err := pconf.Api.GetLdapInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	// VMmanager updates all LDAP settings at once
+	config := ldapConfigFromResource(d)
+	err = pconf.Api.UpdateLdap(d.Id(), config)
+	if err != nil {
+		logger.Error().Msgf("Can't update LDAP %v", err)
+		return diag.FromErr(err)
+	}
+	logger.Info().Msg("End of update of the LDAP resource")
+	return nil
+}
+
+func resourceLdapRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	return _resourceLdapRead(d, meta)
+}
+
+func resourceLdapDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	err := pconf.Api.DeleteLdap(d.Id())
+	return err
+
+}
+
+func _resourceLdapRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_ldap_read")
+
+	config, err := pconf.Api.GetLdapInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	logger.Debug().Msgf("[READ] Received LDAP Config from VMmanager6 API: %+v", config)
+
+	// bind password is not returned by API
+	d.Set("name", config.Name)
+	d.Set("server_url", config.Url)
+	d.Set("bind_dn", config.BindDn)
+	d.Set("search_base", config.SearchBase)
+	d.Set("user_filter", config.UserFilter)
+	d.Set("email_attribute", config.EmailAttribute)
+	d.Set("group_roles", config.GroupRoles)
+	d.Set("start_tls", config.StartTls)
+	d.Set("tls_insecure", config.TlsInsecure)
+	d.Set("ca_cert", config.CaCert)
+
+	// DEBUG print out the read result
+	flatValue, _ := resourceDataToFlatValues(d, ldapResource)
+	delete(flatValue, "bind_password")
+	jsonString, _ := json.Marshal(flatValue)
+	logger.Debug().Msgf("Finished LDAP read resulting in data: '%+v'", string(jsonString))
+
+	return nil
+}
+
+func ldapConfigFromResource(d *schema.ResourceData) ldapConfig {
+	groupRoles := make(map[string]string)
+	for group, role := range d.Get("group_roles").(map[string]interface{}) {
+		groupRoles[group] = role.(string)
+	}
+	return ldapConfig{
+		Name:           d.Get("name").(string),
+		Url:            d.Get("server_url").(string),
+		BindDn:         d.Get("bind_dn").(string),
+		BindPassword:   d.Get("bind_password").(string),
+		SearchBase:     d.Get("search_base").(string),
+		UserFilter:     d.Get("user_filter").(string),
+		EmailAttribute: d.Get("email_attribute").(string),
+		GroupRoles:     groupRoles,
+		StartTls:       d.Get("start_tls").(bool),
+		TlsInsecure:    d.Get("tls_insecure").(bool),
+		CaCert:         d.Get("ca_cert").(string),
+	}
+}
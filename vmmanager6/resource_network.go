@@ -5,13 +5,13 @@ import (
 	//	"strings"
 	"log"
 	//	"strconv"
-	//	"fmt"
 	"encoding/json"
+	"fmt"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	vm6api "github.com/usaafko/vmmanager6-api-go"
-	// "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // using a global variable here so that we have an internally accessible
@@ -30,16 +30,49 @@ func resourceNetwork() *schema.Resource {
 		},
 		Schema: map[string]*schema.Schema{
 			"network": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Ipv4 or Ipv6 Network in CIDR format",
-				ForceNew:    true,
+				Type:             schema.TypeString,
+				Required:         true,
+				Description:      "Ipv4 or Ipv6 Network in CIDR format",
+				ForceNew:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validateCIDR),
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeCIDR(old) == normalizeCIDR(new)
+				},
 			},
 			"gateway": {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Ip address of gateway",
-				ForceNew:    true,
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Ip address of gateway",
+				ValidateFunc: validation.IsIPAddress,
+			},
+			"dns_servers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "Ip addresses of DNS servers for this network",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsIPAddress,
+				},
+			},
+			"vlan": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				Description:  "VLAN id, 0 means without VLAN",
+				ValidateFunc: validation.IntBetween(0, 4094),
+			},
+			"mtu": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				Description:  "MTU of network, 0 means default",
+				ValidateFunc: validation.Any(validation.IntInSlice([]int{0}), validation.IntBetween(576, 9216)),
+			},
+			"ips_used": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "How many ip addresses of network are in use",
 			},
 			"desc": {
 				Type:        schema.TypeString,
@@ -47,6 +80,12 @@ func resourceNetwork() *schema.Resource {
 				Description: "Network Description",
 				Default:     "",
 			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Manage existing network with the same name on create, instead of failing. Without it existing network must be imported",
+			},
 		},
 	}
 	return networkResource
@@ -62,7 +101,7 @@ func resourceNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
-	//defer lock.unlock()
+	defer lock.unlock()
 	client := pconf.Client
 
 	//check if network exists
@@ -73,6 +112,9 @@ func resourceNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	if vmid != "0" {
 		//Network already exists
+		if !d.Get("adopt_existing").(bool) {
+			return adoptExistingError("network", d.Get("network").(string), vmid)
+		}
 		logger.Debug().Msgf("Network already exists id %v", vmid)
 		d.SetId(vmid)
 		_resourceNetworkRead(d, meta)
@@ -89,11 +131,19 @@ func resourceNetworkCreate(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 	d.SetId(vmid)
+	// dns, vlan and mtu can be set only after network is created
+	settings := networkSettingsFromConfig(d)
+	if len(settings.DnsServers) > 0 || settings.Vlan != 0 || settings.Mtu != 0 {
+		err = pconf.Api.UpdateNetwork(vmid, settings)
+		if err != nil {
+			return err
+		}
+	}
 	logger.Debug().Msgf("Finished network read resulting in data: '%+v'", string(jsonString))
 
 	log.Print("[DEBUG][NetworkCreate] vm creation done!")
-	lock.unlock()
-	return nil
+	// read back dns, vlan and mtu defaults chosen by VMmanager
+	return _resourceNetworkRead(d, meta)
 }
 
 func resourceNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -121,6 +171,15 @@ func resourceNetworkUpdate(ctx context.Context, d *schema.ResourceData, meta int
 			return diag.FromErr(err)
 		}
 	}
+	if d.HasChanges("gateway", "dns_servers", "vlan", "mtu") {
+		settings := networkSettingsFromConfig(d)
+		logger.Info().Msgf("Change network settings %+v", settings)
+		err = pconf.Api.UpdateNetwork(d.Id(), settings)
+		if err != nil {
+			logger.Error().Msgf("Can't update network %v", err)
+			return diag.FromErr(err)
+		}
+	}
 	logger.Info().Msg("End of update of the network resource")
 	return nil
 }
@@ -153,7 +212,7 @@ func _resourceNetworkRead(d *schema.ResourceData, meta interface{}) error {
 	// Try to get information on the network. If this call err's out
 	// that indicates the network does not exist. We indicate that to terraform
 	// by calling a SetId("")
-	_, err := client.GetNetworkInfo(d.Id())
+	networkInfo, err := client.GetNetworkInfo(d.Id())
 	if err != nil {
 		d.SetId("")
 		return nil
@@ -168,6 +227,19 @@ func _resourceNetworkRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("network", config.Name)
 	d.Set("gateway", config.Gateway)
 	d.Set("desc", config.Note)
+	var dnsServers []string
+	dnsList, _ := networkInfo["dns"].([]interface{})
+	for _, dns := range dnsList {
+		dnsServer, ok := dns.(string)
+		if !ok {
+			return fmt.Errorf("unexpected dns server %v in network %v", dns, d.Id())
+		}
+		dnsServers = append(dnsServers, dnsServer)
+	}
+	d.Set("dns_servers", dnsServers)
+	d.Set("vlan", mapInt(networkInfo, "vlan"))
+	d.Set("mtu", mapInt(networkInfo, "mtu"))
+	d.Set("ips_used", mapInt(networkInfo, "ip_used"))
 
 	// DEBUG print out the read result
 	flatValue, _ := resourceDataToFlatValues(d, networkResource)
@@ -176,3 +248,16 @@ func _resourceNetworkRead(d *schema.ResourceData, meta interface{}) error {
 
 	return nil
 }
+
+func networkSettingsFromConfig(d *schema.ResourceData) networkSettings {
+	var dnsServers []string
+	for _, dns := range d.Get("dns_servers").([]interface{}) {
+		dnsServers = append(dnsServers, dns.(string))
+	}
+	return networkSettings{
+		Gateway:    d.Get("gateway").(string),
+		DnsServers: dnsServers,
+		Vlan:       d.Get("vlan").(int),
+		Mtu:        d.Get("mtu").(int),
+	}
+}
@@ -5,13 +5,14 @@ import (
 	//	"strings"
 	"log"
 	//	"strconv"
-	//	"fmt"
 	"encoding/json"
+	"fmt"
+	"net"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	vm6api "github.com/usaafko/vmmanager6-api-go"
-	// "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // using a global variable here so that we have an internally accessible
@@ -25,6 +26,7 @@ func resourcePool() *schema.Resource {
 		Read:          resourcePoolRead,
 		UpdateContext: resourcePoolUpdate,
 		Delete:        resourcePoolDelete,
+		CustomizeDiff: resourcePoolCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -35,12 +37,22 @@ func resourcePool() *schema.Resource {
 				Description: "Ipv4 or Ipv6 Network in CIDR format",
 			},
 			"ranges": {
-				Type:        schema.TypeList,
+				Type:        schema.TypeSet,
 				Required:    true,
-				Description: "Range of ips in pool. Format: 192.168.0.1 or 192.168.0.1-192.168.0.10 or 192.168.0.0/24",
+				Description: "Ranges of ips in pool. Format: 192.168.0.1 or 192.168.0.1-192.168.0.10 or 192.168.0.0/24. Ranges must be inside of networks known to VMmanager, for new pool it is checked only when network of the pool already exists",
 				Elem: &schema.Schema{
-					Type: schema.TypeString,
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validation.ToDiagFunc(validateIpRange),
 				},
+				Set: func(v interface{}) int {
+					return schema.HashString(normalizeIpRange(v.(string)))
+				},
+			},
+			"force_delete_ranges": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Delete ranges from pool even if their ips are allocated to VMs",
 			},
 			"desc": {
 				Type:        schema.TypeString,
@@ -48,17 +60,89 @@ func resourcePool() *schema.Resource {
 				Description: "Pool Description",
 				Default:     "",
 			},
+			"clusters": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Computed:      true,
+				Description:   "Array of clusters id, where need to enable pool. Default is cluster 1",
+				ConflictsWith: []string{"cluster"},
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
 			"cluster": {
-				Type:        schema.TypeInt,
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Description:   "Cluster id, where need to enable pool",
+				Deprecated:    "Use clusters instead",
+				ConflictsWith: []string{"clusters"},
+			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
 				Optional:    true,
-				Description: "id of Cluster where need to enable pool",
-				Default:     1,
+				Default:     false,
+				Description: "Manage existing pool with the same name on create, instead of failing. Without it existing pool must be imported",
 			},
 		},
 	}
 	return poolResource
 }
 
+// Check that pool ranges are inside of networks known to VMmanager.
+// Range of new pool outside of all networks is not checked while network
+// of the pool doesn't exist, it can be created in the same apply
+func resourcePoolCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if !d.HasChange("ranges") || !d.NewValueKnown("ranges") {
+		return nil
+	}
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_pool_customize_diff")
+
+	networkList, err := pconf.Api.GetNetworkList()
+	if err != nil {
+		return err
+	}
+	var networks []string
+	poolNetworkExists := false
+	for _, network := range networkList {
+		name, _ := network["name"].(string)
+		if _, _, err := net.ParseCIDR(name); err != nil {
+			logger.Warn().Msgf("Can't parse network %v: %v", network["name"], err)
+			continue
+		}
+		networks = append(networks, name)
+		if normalizeCIDR(name) == normalizeCIDR(d.Get("pool").(string)) {
+			poolNetworkExists = true
+		}
+	}
+	for _, r := range d.Get("ranges").(*schema.Set).List() {
+		first, last, err := parseIpRange(r.(string))
+		if err != nil {
+			return err
+		}
+		found := false
+		overlaps := false
+		for _, network := range networks {
+			_, ipnet, _ := net.ParseCIDR(network)
+			if ipnet.Contains(first) && ipnet.Contains(last) {
+				found = true
+				break
+			}
+			if ipRangesOverlap(network, r.(string)) {
+				overlaps = true
+			}
+		}
+		if found || (d.Id() == "" && !poolNetworkExists && !overlaps) {
+			continue
+		}
+		return fmt.Errorf("Range %v is not inside of any network, create vmmanager6_network first", r)
+	}
+	return nil
+}
+
 func resourcePoolCreate(d *schema.ResourceData, meta interface{}) error {
 	// create a logger for this function
 	logger, _ := CreateSubLogger("resource_pool_create")
@@ -80,31 +164,47 @@ func resourcePoolCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	if vmid != "0" {
 		//Pool already exists
+		if !d.Get("adopt_existing").(bool) {
+			return adoptExistingError("pool", d.Get("pool").(string), vmid)
+		}
 		logger.Debug().Msgf("Pool already exists id %v", vmid)
 		d.SetId(vmid)
 		_resourcePoolRead(d, meta)
 		return nil
 	}
-	genRanges := d.Get("ranges").([]interface{})
+	genRanges := d.Get("ranges").(*schema.Set).List()
 	var myRanges []string
 	for _, Range := range genRanges {
 		myRanges = append(myRanges, Range.(string))
 	}
+	clusters := poolClustersFromConfig(d)
+	if len(clusters) == 0 {
+		clusters = []int{1}
+	}
+	// pool is created on one cluster, then enabled on the others
 	config := vm6api.ConfigNewPool{
 		Name:    d.Get("pool").(string),
 		Note:    d.Get("desc").(string),
 		Ranges:  myRanges,
-		Cluster: d.Get("cluster").(int),
+		Cluster: clusters[0],
 	}
 	vmid, err = config.CreatePool(client)
 	if err != nil {
 		return err
 	}
 	d.SetId(vmid)
+	for _, cluster := range clusters[1:] {
+		logger.Debug().Msgf("Enable pool on cluster %v", cluster)
+		err = pconf.Api.EnablePoolOnCluster(vmid, cluster)
+		if err != nil {
+			_resourcePoolRead(d, meta)
+			return err
+		}
+	}
 	logger.Debug().Msgf("Finished Pool read resulting in data: '%+v'", string(jsonString))
 
 	log.Print("[DEBUG][PoolCreate] vm creation done!")
-	return nil
+	return _resourcePoolRead(d, meta)
 }
 
 func resourcePoolUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -133,39 +233,119 @@ func resourcePoolUpdate(ctx context.Context, d *schema.ResourceData, meta interf
 	}
 	if d.HasChange("ranges") {
 		oldValuesRaw, newValuesRaw := d.GetChange("ranges")
-		oldValues := oldValuesRaw.([]interface{})
-		newValues := newValuesRaw.([]interface{})
-		for i := range oldValues {
-			if !InterfaceStringsContains(newValues, oldValues[i]) { //remove something
-				curRanges := config["ipnets"].([]interface{})
-				for _, v := range curRanges {
-					testRange := v.(map[string]interface{})["name"].(string)
-					if oldValues[i].(string) == testRange {
-						logger.Debug().Msgf("Delete range from pool %v", testRange)
-						err = client.DeletePoolRange(int(v.(map[string]interface{})["id"].(float64)))
-						if err != nil {
-							return diag.FromErr(err)
-						}
+		oldValues := oldValuesRaw.(*schema.Set)
+		newValues := newValuesRaw.(*schema.Set)
+		removed := oldValues.Difference(newValues).List()
+		added := newValues.Difference(oldValues).List()
+
+		// deleting range releases its ips, so check them before any change
+		if len(removed) > 0 && !d.Get("force_delete_ranges").(bool) {
+			ips, err := pconf.Api.GetPoolIps(d.Id())
+			if err != nil {
+				return diag.FromErr(err)
+			}
+			for _, r := range removed {
+				for _, ip := range ips {
+					// ip can be reserved in pool without VM
+					if ip.VmId != 0 && ipRangesOverlap(ip.Addr, r.(string)) {
+						return diag.Errorf("Can't delete range %v from pool, ip %v is allocated to VM %v. Set force_delete_ranges to delete it anyway", r, ip.Addr, ip.VmId)
 					}
 				}
-
 			}
 		}
-		for i := range newValues {
-			if !InterfaceStringsContains(oldValues, newValues[i]) { //that's new value
-				logger.Debug().Msgf("Add range to pool %v", newValues[i].(string))
-				err = client.CreatePoolRange(d.Id(), newValues[i].(string))
+		// add new ranges first, so pool always has free ips.
+		// New range which overlaps removed one can be added only after delete
+		var addLater []interface{}
+		for _, r := range added {
+			overlaps := false
+			for _, old := range removed {
+				if ipRangesOverlap(r.(string), old.(string)) {
+					overlaps = true
+				}
+			}
+			if overlaps {
+				addLater = append(addLater, r)
+				continue
+			}
+			logger.Debug().Msgf("Add range to pool %v", r)
+			err = client.CreatePoolRange(d.Id(), r.(string))
+			if err != nil {
+				return poolUpdateFailed(d, meta, err)
+			}
+		}
+		curRanges := config["ipnets"].([]interface{})
+		for _, r := range removed {
+			for _, v := range curRanges {
+				ipnet := v.(map[string]interface{})
+				if normalizeIpRange(ipnet["name"].(string)) == normalizeIpRange(r.(string)) {
+					logger.Debug().Msgf("Delete range from pool %v", ipnet["name"])
+					err = client.DeletePoolRange(mapInt(ipnet, "id"))
+					if err != nil {
+						return poolUpdateFailed(d, meta, err)
+					}
+				}
+			}
+		}
+		for _, r := range addLater {
+			logger.Debug().Msgf("Add range to pool %v", r)
+			err = client.CreatePoolRange(d.Id(), r.(string))
+			if err != nil {
+				return poolUpdateFailed(d, meta, err)
+			}
+		}
+	}
+	if d.HasChanges("clusters", "cluster") {
+		oldValuesRaw, _ := d.GetChange("clusters")
+		oldValues := oldValuesRaw.([]interface{})
+		var newValues []interface{}
+		for _, cluster := range poolClustersFromConfig(d) {
+			newValues = append(newValues, cluster)
+		}
+		// enable first, so pool is not left without clusters in case of error
+		for _, cluster := range newValues {
+			if !InterfaceIntsContains(oldValues, cluster) {
+				logger.Debug().Msgf("Enable pool on cluster %v", cluster)
+				err = pconf.Api.EnablePoolOnCluster(d.Id(), cluster.(int))
 				if err != nil {
-					return diag.FromErr(err)
+					return poolUpdateFailed(d, meta, err)
 				}
 			}
 		}
-
+		for _, cluster := range oldValues {
+			if !InterfaceIntsContains(newValues, cluster) {
+				logger.Debug().Msgf("Disable pool on cluster %v", cluster)
+				err = pconf.Api.DisablePoolOnCluster(d.Id(), cluster.(int))
+				if err != nil {
+					return poolUpdateFailed(d, meta, err)
+				}
+			}
+		}
+		d.Set("clusters", newValues)
 	}
 	logger.Info().Msg("End of update of the pool resource")
 	return nil
 }
 
+// Clusters from clusters list or deprecated cluster
+func poolClustersFromConfig(d *schema.ResourceData) []int {
+	if cluster := d.Get("cluster").(int); cluster != 0 {
+		return []int{cluster}
+	}
+	var clusters []int
+	for _, cluster := range d.Get("clusters").([]interface{}) {
+		clusters = append(clusters, cluster.(int))
+	}
+	return clusters
+}
+
+// Pool can be changed partially, read it back so state shows what was really done
+func poolUpdateFailed(d *schema.ResourceData, meta interface{}, err error) diag.Diagnostics {
+	logger, _ := CreateSubLogger("resource_pool_update")
+	logger.Error().Msgf("Can't update pool %v", err)
+	_resourcePoolRead(d, meta)
+	return diag.FromErr(err)
+}
+
 func resourcePoolRead(d *schema.ResourceData, meta interface{}) error {
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
@@ -194,7 +374,7 @@ func _resourcePoolRead(d *schema.ResourceData, meta interface{}) error {
 	// Try to get information on the Pool. If this call err's out
 	// that indicates the Pool does not exist. We indicate that to terraform
 	// by calling a SetId("")
-	_, err := client.GetPoolInfo(d.Id())
+	poolInfo, err := client.GetPoolInfo(d.Id())
 	if err != nil {
 		d.SetId("")
 		return nil
@@ -214,6 +394,24 @@ func _resourcePoolRead(d *schema.ResourceData, meta interface{}) error {
 		getRanges = append(getRanges, val.Range)
 	}
 	d.Set("ranges", getRanges)
+	clusters, err := mapIdList(poolInfo, "clusters")
+	if err != nil {
+		return err
+	}
+	clusters = sortLike(d.Get("clusters").([]interface{}), clusters)
+	d.Set("clusters", clusters)
+	// deprecated cluster keeps its value while pool is enabled on it
+	if cluster := d.Get("cluster").(int); cluster != 0 {
+		enabled := false
+		for _, v := range clusters {
+			if v == cluster {
+				enabled = true
+			}
+		}
+		if !enabled {
+			d.Set("cluster", 0)
+		}
+	}
 	// DEBUG print out the read result
 	flatValue, _ := resourceDataToFlatValues(d, poolResource)
 	jsonString, _ := json.Marshal(flatValue)
@@ -0,0 +1,136 @@
+package vmmanager6
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// using a global variable here so that we have an internally accessible
+// way to look into our own resource definition. Useful for dynamically doing typecasts
+// so that we can print (debug) our ResourceData constructs
+var projectResource *schema.Resource
+
+func resourceProject() *schema.Resource {
+	projectResource = &schema.Resource{
+		Create:        resourceProjectCreate,
+		Read:          resourceProjectRead,
+		UpdateContext: resourceProjectUpdate,
+		Delete:        resourceProjectDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of project",
+			},
+			"comment": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Project Description",
+				Default:     "",
+			},
+		},
+	}
+	return projectResource
+}
+
+func resourceProjectCreate(d *schema.ResourceData, meta interface{}) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_project_create")
+
+	// DEBUG print out the create request
+	flatValue, _ := resourceDataToFlatValues(d, projectResource)
+	jsonString, _ := json.Marshal(flatValue)
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	config := projectConfig{
+		Name:    d.Get("name").(string),
+		Comment: d.Get("comment").(string),
+	}
+	vmid, err := pconf.Api.CreateProject(config)
+	if err != nil {
+		return err
+	}
+	d.SetId(vmid)
+	logger.Debug().Msgf("Finished project read resulting in data: '%+v'", string(jsonString))
+
+	log.Print("[DEBUG][ProjectCreate] creation done!")
+	return nil
+}
+
+func resourceProjectUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_project_update")
+
+	logger.Info().Msg("Starting update of the project resource")
+
+	_, err := pconf.Api.GetProjectInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	if d.HasChanges("name", "comment") {
+		err = pconf.Api.UpdateProject(d.Id(), projectConfig{
+			Name:    d.Get("name").(string),
+			Comment: d.Get("comment").(string),
+		})
+		logger.Info().Msg("Change project name and comment")
+		if err != nil {
+			logger.Error().Msgf("Can't update project %v", err)
+			return diag.FromErr(err)
+		}
+	}
+	logger.Info().Msg("End of update of the project resource")
+	return nil
+}
+
+func resourceProjectRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	return _resourceProjectRead(d, meta)
+}
+
+func resourceProjectDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	err := pconf.Api.DeleteProject(d.Id())
+	return err
+
+}
+
+func _resourceProjectRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_project_read")
+
+	config, err := pconf.Api.GetProjectInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	logger.Debug().Msgf("[READ] Received Project Config from VMmanager6 API: %+v", config)
+
+	d.Set("name", config.Name)
+	d.Set("comment", config.Comment)
+
+	return nil
+}
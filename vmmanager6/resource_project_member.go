@@ -0,0 +1,113 @@
+package vmmanager6
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceProjectMember() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceProjectMemberCreate,
+		Read:   resourceProjectMemberRead,
+		Delete: resourceProjectMemberDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"project": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "id of project",
+			},
+			"account": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "VMmanager user id",
+			},
+		},
+	}
+}
+
+func resourceProjectMemberCreate(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	project := strconv.Itoa(d.Get("project").(int))
+	account := strconv.Itoa(d.Get("account").(int))
+	err := pconf.Api.AddProjectMember(project, account)
+	if err != nil {
+		return err
+	}
+	d.SetId(clusterResourceId(project, account))
+
+	log.Print("[DEBUG][ProjectMemberCreate] creation done!")
+	return nil
+}
+
+func resourceProjectMemberRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_project_member_read")
+
+	// id is project/account, so import works with it
+	project, account, err := parseClusterResourceId(d.Id())
+	if err != nil {
+		return err
+	}
+	_, err = pconf.Api.GetProjectInfo(project)
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+	members, err := pconf.Api.GetProjectMembers(project)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Msgf("[READ] Received project members from VMmanager6 API: %+v", members)
+
+	found := false
+	for _, member := range members {
+		if strconv.Itoa(mapInt(member, "id")) == account {
+			found = true
+			break
+		}
+	}
+	if !found {
+		d.SetId("")
+		return nil
+	}
+
+	projectId, err := strconv.Atoi(project)
+	if err != nil {
+		return fmt.Errorf("invalid project id %v", project)
+	}
+	accountId, err := strconv.Atoi(account)
+	if err != nil {
+		return fmt.Errorf("invalid account id %v", account)
+	}
+	d.Set("project", projectId)
+	d.Set("account", accountId)
+	return nil
+}
+
+func resourceProjectMemberDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	project, account, err := parseClusterResourceId(d.Id())
+	if err != nil {
+		return err
+	}
+	err = pconf.Api.DeleteProjectMember(project, account)
+	return err
+
+}
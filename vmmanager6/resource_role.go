@@ -0,0 +1,161 @@
+package vmmanager6
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// Built-in roles are @admin, @advanced_user, @user. Custom roles use the same format
+var rxRoleName = regexp.MustCompile(`^@[A-Za-z0-9_-]+$`)
+
+// using a global variable here so that we have an internally accessible
+// way to look into our own resource definition. Useful for dynamically doing typecasts
+// so that we can print (debug) our ResourceData constructs
+var roleResource *schema.Resource
+
+func resourceRole() *schema.Resource {
+	roleResource = &schema.Resource{
+		Create:        resourceRoleCreate,
+		Read:          resourceRoleRead,
+		UpdateContext: resourceRoleUpdate,
+		Delete:        resourceRoleDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Role name, must start with @. Use it as role of vmmanager6_account",
+				ValidateFunc: validation.StringMatch(rxRoleName, "must start with @ and contain only letters, digits, _ and -"),
+			},
+			"desc": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Role Description",
+				Default:     "",
+			},
+			"permissions": {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Description: "Set of VMmanager permissions for role",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+	return roleResource
+}
+
+func resourceRoleCreate(d *schema.ResourceData, meta interface{}) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_role_create")
+
+	// DEBUG print out the create request
+	flatValue, _ := resourceDataToFlatValues(d, roleResource)
+	jsonString, _ := json.Marshal(flatValue)
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	config := roleConfig{
+		Name:        d.Get("name").(string),
+		Description: d.Get("desc").(string),
+		Permissions: rolePermissionsFromConfig(d),
+	}
+	vmid, err := pconf.Api.CreateRole(config)
+	if err != nil {
+		return err
+	}
+	d.SetId(vmid)
+	logger.Debug().Msgf("Finished role read resulting in data: '%+v'", string(jsonString))
+
+	log.Print("[DEBUG][RoleCreate] creation done!")
+	return nil
+}
+
+func resourceRoleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_role_update")
+
+	logger.Info().Msg("Starting update of the role resource")
+
+	_, err := pconf.Api.GetRoleInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	if d.HasChanges("desc", "permissions") {
+		err = pconf.Api.UpdateRole(d.Id(), roleConfig{
+			Description: d.Get("desc").(string),
+			Permissions: rolePermissionsFromConfig(d),
+		})
+		logger.Info().Msg("Change role desc and permissions")
+		if err != nil {
+			logger.Error().Msgf("Can't update role %v", err)
+			return diag.FromErr(err)
+		}
+	}
+	logger.Info().Msg("End of update of the role resource")
+	return nil
+}
+
+func resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	return _resourceRoleRead(d, meta)
+}
+
+func resourceRoleDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	err := pconf.Api.DeleteRole(d.Id())
+	return err
+
+}
+
+func _resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_role_read")
+
+	config, err := pconf.Api.GetRoleInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	logger.Debug().Msgf("[READ] Received Role Config from VMmanager6 API: %+v", config)
+
+	d.Set("name", config.Name)
+	d.Set("desc", config.Description)
+	d.Set("permissions", config.Permissions)
+
+	return nil
+}
+
+func rolePermissionsFromConfig(d *schema.ResourceData) []string {
+	var permissions []string
+	for _, permission := range d.Get("permissions").(*schema.Set).List() {
+		permissions = append(permissions, permission.(string))
+	}
+	return permissions
+}
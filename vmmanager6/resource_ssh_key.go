@@ -0,0 +1,230 @@
+package vmmanager6
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	vm6api "github.com/usaafko/vmmanager6-api-go"
+	"golang.org/x/crypto/ssh"
+)
+
+// using a global variable here so that we have an internally accessible
+// way to look into our own resource definition. Useful for dynamically doing typecasts
+// so that we can print (debug) our ResourceData constructs
+var sshKeyResource *schema.Resource
+
+func resourceSshKey() *schema.Resource {
+	sshKeyResource = &schema.Resource{
+		Create: resourceSshKeyCreate,
+		Read:   resourceSshKeyRead,
+		Delete: resourceSshKeyDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"account_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "VMmanager user id. Don't manage ssh keys of this account with vmmanager6_account ssh_keys at the same time",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "name of public ssh key",
+			},
+			"ssh_pub_key": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "public ssh key in OpenSSH format",
+				ValidateFunc: validateSshPubKey,
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.TrimSpace(old) == strings.TrimSpace(new)
+				},
+			},
+			"fingerprint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA256 fingerprint of public ssh key",
+			},
+		},
+	}
+	return sshKeyResource
+}
+
+func resourceSshKeyCreate(d *schema.ResourceData, meta interface{}) error {
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_ssh_key_create")
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	client := pconf.Client
+
+	accountId := strconv.Itoa(d.Get("account_id").(int))
+	_, err := client.GetAccountInfo(accountId)
+	if err != nil {
+		return fmt.Errorf("Can't find account %v: %v", accountId, err)
+	}
+
+	key := vm6api.SshKeyConfig{}
+	j, err := json.Marshal(map[string]interface{}{
+		"name":        d.Get("name").(string),
+		"ssh_pub_key": strings.TrimSpace(d.Get("ssh_pub_key").(string)),
+	})
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(j, &key)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Msgf("adding key %#v", key)
+	err = client.AccountAddSshKey(accountId, key)
+	if err != nil {
+		return err
+	}
+
+	// API doesn't return id of new key, find it by public key
+	sshKey, err := findAccountSshKey(client, accountId, func(k map[string]interface{}) bool {
+		return strings.TrimSpace(fmt.Sprint(k["ssh_pub_key"])) == strings.TrimSpace(d.Get("ssh_pub_key").(string))
+	})
+	if err != nil {
+		return err
+	}
+	if sshKey == nil {
+		return fmt.Errorf("Can't find ssh key %v of account %v after creation", d.Get("name"), accountId)
+	}
+	d.SetId(clusterResourceId(accountId, strconv.Itoa(mapInt(sshKey, "id"))))
+
+	err = _resourceSshKeyRead(d, meta)
+	if err != nil {
+		return err
+	}
+
+	log.Print("[DEBUG][SshKeyCreate] creation done!")
+	return nil
+}
+
+func resourceSshKeyRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	return _resourceSshKeyRead(d, meta)
+}
+
+func resourceSshKeyDelete(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+
+	accountId, keyId, err := parseClusterResourceId(d.Id())
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(keyId)
+	if err != nil {
+		return err
+	}
+	err = pconf.Api.AccountDeleteSshKey(accountId, id)
+	return err
+
+}
+
+func _resourceSshKeyRead(d *schema.ResourceData, meta interface{}) error {
+	pconf := meta.(*providerConfiguration)
+	client := pconf.Client
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_ssh_key_read")
+
+	// id is account_id/key_id, so import works with it
+	accountId, keyId, err := parseClusterResourceId(d.Id())
+	if err != nil {
+		return err
+	}
+	_, err = client.GetAccountInfo(accountId)
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+	sshKey, err := findAccountSshKey(client, accountId, func(k map[string]interface{}) bool {
+		return strconv.Itoa(mapInt(k, "id")) == keyId
+	})
+	if err != nil {
+		return err
+	}
+	if sshKey == nil {
+		d.SetId("")
+		return nil
+	}
+
+	logger.Debug().Msgf("[READ] Received ssh key from VMmanager6 API: %+v", sshKey)
+
+	pubKey := fmt.Sprint(sshKey["ssh_pub_key"])
+	account, _ := strconv.Atoi(accountId)
+	d.Set("account_id", account)
+	d.Set("name", sshKey["name"])
+	d.Set("ssh_pub_key", pubKey)
+	fingerprint, err := sshKeyFingerprint(pubKey)
+	if err != nil {
+		return err
+	}
+	d.Set("fingerprint", fingerprint)
+
+	return nil
+}
+
+func findAccountSshKey(client *vm6api.Client, accountId string, match func(map[string]interface{}) bool) (map[string]interface{}, error) {
+	sshKeys, err := client.AccountGetSshKeys(accountId)
+	if err != nil {
+		return nil, err
+	}
+	for _, sshKey := range sshKeys {
+		if match(sshKey) {
+			return sshKey, nil
+		}
+	}
+	return nil, nil
+}
+
+// Parse OpenSSH public key: "type base64-key [comment]"
+func parseSshPubKey(pubKey string) (ssh.PublicKey, error) {
+	key, _, options, rest, err := ssh.ParseAuthorizedKey([]byte(pubKey))
+	if err != nil {
+		return nil, fmt.Errorf("must be in OpenSSH format: type key [comment]: %v", err)
+	}
+	if len(options) > 0 {
+		return nil, fmt.Errorf("options %v are not allowed", strings.Join(options, ","))
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return nil, fmt.Errorf("must be a single key")
+	}
+	if keyType := strings.Fields(pubKey)[0]; keyType != key.Type() {
+		return nil, fmt.Errorf("key type %v doesn't match %v", key.Type(), keyType)
+	}
+	return key, nil
+}
+
+func validateSshPubKey(v interface{}, k string) (warnings []string, errors []error) {
+	_, err := parseSshPubKey(v.(string))
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q is not valid public ssh key: %v", k, err))
+	}
+	return
+}
+
+// Fingerprint in the same format as ssh-keygen -l
+func sshKeyFingerprint(pubKey string) (string, error) {
+	key, err := parseSshPubKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(key), nil
+}
@@ -0,0 +1,40 @@
+package vmmanager6
+
+import "testing"
+
+func TestSshKeyFingerprint(t *testing.T) {
+	tests := []struct {
+		key         string
+		fingerprint string
+		err         bool
+	}{
+		{
+			key:         "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJCniyc/mWdeHgg5FdNdnzwVwv3dbPGaYc0HX6Huhq2w user@host",
+			fingerprint: "SHA256:jYRYteWQ8AICdujQjIwAAwt5DduMA2wEaWu1wB2YMdI",
+		},
+		{
+			key:         "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJCniyc/mWdeHgg5FdNdnzwVwv3dbPGaYc0HX6Huhq2w\n",
+			fingerprint: "SHA256:jYRYteWQ8AICdujQjIwAAwt5DduMA2wEaWu1wB2YMdI",
+		},
+		// no key material
+		{key: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5", err: true},
+		// type doesn't match key
+		{key: "ssh-rsa AAAAC3NzaC1lZDI1NTE5AAAAIJCniyc/mWdeHgg5FdNdnzwVwv3dbPGaYc0HX6Huhq2w", err: true},
+		{key: "ssh-ed25519 not-base64", err: true},
+		{key: "ssh-ed25519", err: true},
+		{key: "", err: true},
+		{key: `command="id" ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJCniyc/mWdeHgg5FdNdnzwVwv3dbPGaYc0HX6Huhq2w`, err: true},
+	}
+	for _, tt := range tests {
+		fingerprint, err := sshKeyFingerprint(tt.key)
+		if tt.err {
+			if err == nil {
+				t.Errorf("sshKeyFingerprint(%q) expected error, got %v", tt.key, fingerprint)
+			}
+			continue
+		}
+		if err != nil || fingerprint != tt.fingerprint {
+			t.Errorf("sshKeyFingerprint(%q) = %v, %v, want %v", tt.key, fingerprint, err, tt.fingerprint)
+		}
+	}
+}
@@ -20,12 +20,16 @@ import (
 // so that we can print (debug) our ResourceData constructs
 var thisResource *schema.Resource
 
+// Changing any of them reinstalls VM OS
+var vmReinstallKeys = []string{"os", "reinstall_trigger", "user", "user_data", "network_data", "ssh_keys", "ssh_key_ids"}
+
 func resourceVmQemu() *schema.Resource {
 	thisResource = &schema.Resource{
 		Create:        resourceVmQemuCreate,
 		Read:          resourceVmQemuRead,
 		UpdateContext: resourceVmQemuUpdate,
 		Delete:        resourceVmQemuDelete,
+		CustomizeDiff: resourceVmQemuCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -91,8 +95,8 @@ func resourceVmQemu() *schema.Resource {
 			"account": {
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Default:     3,
-				Description: "VMmanager user id",
+				Computed:    true,
+				Description: "VMmanager user id. Default is the account provider is authenticated with",
 			},
 			"domain": {
 				Type:        schema.TypeString,
@@ -101,17 +105,35 @@ func resourceVmQemu() *schema.Resource {
 			},
 			"password": {
 				Type:      schema.TypeString,
-				Required:  true,
+				Optional:  true,
 				Sensitive: true,
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
-					return new == "**********"
+					return new == "**********" || passwordDiffSuppress(k, old, new, d)
 				},
-				Description: "Password for VM",
+				Description: "Password for VM. Can be omitted if ssh_keys, ssh_key_ids or generate_password are set",
+			},
+			"password_write_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Don't save password to state, only its salted PBKDF2 hash. Password changes are still detected",
+			},
+			"generate_password": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Generate random password for VM, if password is not set. Existing VM gets new password, when it is enabled",
+			},
+			"generated_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Password generated with generate_password",
 			},
 			"os": {
 				Type:        schema.TypeInt,
 				Required:    true,
-				Description: "VMmanager 6 template id",
+				Description: "VMmanager 6 template id. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
 			},
 			"cpu_mode": {
 				Type:        schema.TypeString,
@@ -239,47 +261,133 @@ func resourceVmQemu() *schema.Resource {
 					},
 				},
 			},
+			"user": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Cloud-init user, that will get ssh keys and password. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
+			},
+			"user_data": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Cloud-init user-data. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
+			},
+			"network_data": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Cloud-init network-data. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
+			},
+			"ssh_keys": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Public ssh keys, that will be added to VM. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"ssh_key_ids": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Ids of account public ssh keys (vmmanager6_account.ssh_keys), that will be added to VM. Changing it reinstalls VM OS and wipes VM disk, although plan shows it as in-place update",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"email_mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Email notification mode for VM creation and OS reinstall. Can be none, owner, admin. Default is pm_email_mode of provider, if it is not set too, VMmanager default notifications are sent on VM creation and none on OS reinstall",
+				ValidateFunc: validation.StringInSlice(emailModes, false),
+			},
+			"tags": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "VM tags. Merged with pm_default_tags of provider",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"tags_all": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "All VM tags, including pm_default_tags of provider",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Deny VM deletion. Must be set to false before the VM can be destroyed",
+			},
+			"reinstall_protection": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Deny OS reinstall when os, reinstall_trigger or cloud-init settings (user, user_data, network_data, ssh_keys, ssh_key_ids) are changed. Must be set to false before the VM can be reinstalled",
+			},
 			"recipes": {
 				Type:        schema.TypeList,
 				Optional:    true,
 				ForceNew:    true,
 				Description: "Array of recipes and params",
+				Elem:        vmRecipeSchema(true),
+			},
+			"rescue_mode": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Boot VM into rescue mode. It is read from VM state, so switching rescue mode in VMmanager panel shows as a change",
+			},
+			"reinstall_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value reinstalls VM OS with reinstall_recipes and wipes VM disk, although plan shows it as in-place update",
+			},
+			"reinstall_recipes": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Array of recipes and params, applied when VM OS is reinstalled",
+				Elem:        vmRecipeSchema(false),
+			},
+		},
+	}
+	return thisResource
+}
+
+func vmRecipeSchema(forceNew bool) *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"recipe": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "id of recipe",
+				ForceNew:    forceNew,
+			},
+			"recipe_params": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Array of recipe params",
+				ForceNew:    forceNew,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
-						"recipe": {
-							Type:        schema.TypeInt,
+						"name": {
+							Type:        schema.TypeString,
 							Required:    true,
-							Description: "id of recipe",
-							ForceNew:    true,
+							Description: "param name",
+							ForceNew:    forceNew,
 						},
-						"recipe_params": {
-							Type:        schema.TypeList,
-							Optional:    true,
-							Description: "Array of recipe params",
-							ForceNew:    true,
-							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"name": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "param name",
-										ForceNew:    true,
-									},
-									"value": {
-										Type:        schema.TypeString,
-										Required:    true,
-										Description: "param value",
-										ForceNew:    true,
-									},
-								},
-							},
+						"value": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "param value",
+							ForceNew:    forceNew,
 						},
 					},
 				},
 			},
 		},
 	}
-	return thisResource
 }
 
 func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) error {
@@ -292,8 +400,7 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) error {
 
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
-	//defer lock.unlock()
-	client := pconf.Client
+	defer lock.unlock()
 
 	// Collect pools from config
 	ipv4_pools := d.Get("ipv4_pools").([]interface{})
@@ -303,41 +410,114 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	// Collect recipes from config
-	recipes_config := d.Get("recipes").([]interface{})
-	var recipes_api []vm6api.RecipeConfig
-
-	j, err := json.Marshal(recipes_config)
-	err = json.Unmarshal(j, &recipes_api)
+	recipes_api, err := vmRecipesFromConfig(d, "recipes")
 	if err != nil {
 		return err
 	}
 
-	config := vm6api.ConfigNewQemu{
-		Name:             d.Get("name").(string),
-		Description:      d.Get("desc").(string),
-		Memory:           d.Get("memory").(int),
-		QemuCores:        d.Get("cores").(int),
-		QemuDisks:        d.Get("disk").(int),
-		Cluster:          d.Get("cluster").(int),
-		Node:             d.Get("node").(int),
-		Account:          d.Get("account").(int),
-		Domain:           d.Get("domain").(string),
-		Password:         d.Get("password").(string),
-		IPv4:             d.Get("ipv4_number").(int),
-		Os:               d.Get("os").(int),
-		Anti_spoofing:    d.Get("anti_spoofing").(bool),
-		CpuMode:          d.Get("cpu_mode").(string),
-		Preset:           d.Get("preset").(int),
-		IPv4Pools:        ipv4_pools_int,
-		Recipes:          recipes_api,
-		CustomInterfaces: d.Get("custom_interfaces").([]interface{}),
-		Vxlans:           d.Get("vxlan").([]interface{}),
-	}
-	vmid, err := config.CreateVm(client)
+	account := d.Get("account").(int)
+	if account == 0 {
+		currentAccount, err := pconf.Api.GetCurrentAccountId()
+		if err != nil {
+			return err
+		}
+		account, err = strconv.Atoi(currentAccount)
+		if err != nil {
+			return err
+		}
+	}
+
+	password := configPassword(d)
+	if password == "" && d.Get("generate_password").(bool) {
+		password, err = generatePassword(16)
+		if err != nil {
+			return err
+		}
+		d.Set("generated_password", password)
+	}
+
+	var vmid int
+	cloudInit := vmCloudInitFromConfig(d)
+	sshKeys := vmSshKeysFromConfig(d)
+	emailMode := vmEmailMode(d, pconf, "")
+	if cloudInit != nil || len(sshKeys) > 0 || vmRecipesHaveParams(recipes_api) || emailMode != "" {
+		// ConfigNewQemu has no cloud-init, recipe params and email mode,
+		// so such VM is created with provider's API client
+		config := vmCreateConfig{
+			Name:             d.Get("name").(string),
+			Description:      d.Get("desc").(string),
+			Memory:           d.Get("memory").(int),
+			Cores:            d.Get("cores").(int),
+			Disk:             d.Get("disk").(int),
+			Cluster:          d.Get("cluster").(int),
+			Node:             d.Get("node").(int),
+			Account:          account,
+			Domain:           d.Get("domain").(string),
+			Password:         password,
+			IPv4:             d.Get("ipv4_number").(int),
+			Os:               d.Get("os").(int),
+			AntiSpoofing:     d.Get("anti_spoofing").(bool),
+			CpuMode:          d.Get("cpu_mode").(string),
+			Preset:           d.Get("preset").(int),
+			IPv4Pools:        ipv4_pools_int,
+			Recipes:          recipes_api,
+			CustomInterfaces: vmCustomInterfacesFromConfig(d),
+			Vxlans:           vmVxlansFromConfig(d),
+			CloudInit:        cloudInit,
+			SshKeys:          sshKeys,
+			EmailMode:        emailMode,
+		}
+		vmid, err = pconf.Api.CreateVm(config)
+	} else {
+		var recipes []vm6api.RecipeConfig
+		for _, recipe := range recipes_api {
+			recipes = append(recipes, vm6api.RecipeConfig{Recipe: recipe.Recipe})
+		}
+		config := vm6api.ConfigNewQemu{
+			Name:             d.Get("name").(string),
+			Description:      d.Get("desc").(string),
+			Memory:           d.Get("memory").(int),
+			QemuCores:        d.Get("cores").(int),
+			QemuDisks:        d.Get("disk").(int),
+			Cluster:          d.Get("cluster").(int),
+			Node:             d.Get("node").(int),
+			Account:          account,
+			Domain:           d.Get("domain").(string),
+			Password:         password,
+			IPv4:             d.Get("ipv4_number").(int),
+			Os:               d.Get("os").(int),
+			Anti_spoofing:    d.Get("anti_spoofing").(bool),
+			CpuMode:          d.Get("cpu_mode").(string),
+			Preset:           d.Get("preset").(int),
+			IPv4Pools:        ipv4_pools_int,
+			Recipes:          recipes,
+			CustomInterfaces: d.Get("custom_interfaces").([]interface{}),
+			Vxlans:           d.Get("vxlan").([]interface{}),
+		}
+		vmid, err = config.CreateVm(pconf.Client)
+	}
+	if vmid != 0 {
+		// keep VM in state, even if it failed to start, so it is tainted and not lost
+		d.SetId(fmt.Sprint(vmid))
+	}
 	if err != nil {
 		return err
 	}
-	d.SetId(fmt.Sprint(vmid))
+	passwordToState(d)
+
+	if tags := mergeTags(pconf.DefaultTags, d.Get("tags").(map[string]interface{})); len(tags) > 0 {
+		err = pconf.Api.SetVmTags(vmid, tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Get("rescue_mode").(bool) {
+		err = pconf.Api.StartRescueMode(vmid)
+		if err != nil {
+			return err
+		}
+	}
 
 	logger.Debug().Int("vmid", vmid).Msgf("Finished VM read resulting in data: '%+v'", string(jsonString))
 	err = _resourceVmQemuRead(d, meta)
@@ -346,13 +526,28 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 
 	log.Print("[DEBUG][QemuVmCreate] vm creation done!")
-	lock.unlock()
 	return nil
 }
 
 func resourceVmQemuUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	// Check protection before touching anything, so we don't leave VM half updated
+	if d.HasChanges(vmReinstallKeys...) {
+		// use old value, so protection can't be disabled in the same apply
+		protected, _ := d.GetChange("reinstall_protection")
+		if protected.(bool) {
+			return diag.Diagnostics{
+				{
+					Severity: diag.Error,
+					Summary:  "VM is protected from reinstall",
+					Detail:   fmt.Sprintf("Changing os, reinstall_trigger or cloud-init settings will reinstall VM %s and wipe its disk. Set reinstall_protection = false and apply before reinstall.", d.Id()),
+				},
+			}
+		}
+	}
+
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
 	// create a logger for this function
 	logger, _ := CreateSubLogger("resource_vm_update")
 
@@ -371,15 +566,6 @@ func resourceVmQemuUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		return diag.FromErr(err)
 	}
 
-	if d.HasChange("disk") {
-		oldValuesRaw, newValuesRaw := d.GetChange("disk")
-		oldValues := oldValuesRaw.(int)
-		newValues := newValuesRaw.(int)
-		if oldValues > newValues {
-			return diag.Errorf("Can't shrink VM's disk")
-		}
-	}
-
 	// VMmanager has different APIs to change things.
 	// 1. Resources
 	if d.HasChanges("cores", "memory", "cpu_mode") {
@@ -407,27 +593,62 @@ func resourceVmQemuUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 
 	}
+	var diags diag.Diagnostics
+	// Generate password, when generate_password is enabled for existing VM.
+	// It goes before reinstall, so reinstalled VM gets it too
+	if configPassword(d) == "" && d.Get("generate_password").(bool) && d.Get("generated_password").(string) == "" {
+		logger.Debug().Int("vmid", vmID).Msgf("Generate VM password")
+		password, err := generatePassword(16)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		err = client.ChangePassword(vmr, password)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("generated_password", password)
+	}
 	// 3. Change OS
-	if d.HasChange("os") {
-		config := vm6api.ReinstallOS{
-			Id:        d.Get("os").(int),
-			Password:  d.Get("password").(string),
-			EmailMode: "saas_only",
+	if d.HasChanges(vmReinstallKeys...) {
+		recipes, err := vmRecipesFromConfig(d, "reinstall_recipes")
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		password := configPassword(d)
+		if password == "" {
+			password = d.Get("generated_password").(string)
+		}
+		// ReinstallOS has no cloud-init and recipes, so VM is reinstalled with provider's API client
+		config := vmReinstallConfig{
+			Os:        d.Get("os").(int),
+			Password:  password,
+			EmailMode: vmEmailMode(d, pconf, "none"),
+			CloudInit: vmCloudInitFromConfig(d),
+			SshKeys:   vmSshKeysFromConfig(d),
+			Recipes:   recipes,
 		}
 		logger.Debug().Int("vmid", vmID).Msgf("Updating VM with the following configuration: %+v", config)
-		err = config.ReinstallOS(vmr, client)
+		err = pconf.Api.ReinstallVm(vmID, config)
 		if err != nil {
 			return diag.FromErr(err)
 		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "VM OS was reinstalled",
+			Detail:   fmt.Sprintf("VM %s was reinstalled, because os, reinstall_trigger or cloud-init settings were changed. All data on VM disk is lost.", d.Id()),
+		})
 	}
 	// 4. Change password
-	if d.HasChange("password") {
+	if password := configPassword(d); d.HasChange("password") && password != "" {
 		logger.Debug().Int("vmid", vmID).Msgf("Updating VM password")
-		err = client.ChangePassword(vmr, d.Get("password").(string))
+		err = client.ChangePassword(vmr, password)
 		if err != nil {
 			return diag.FromErr(err)
 		}
 	}
+	if d.HasChanges("password", "password_write_only") {
+		passwordToState(d)
+	}
 	// 5. Owner
 	if d.HasChange("account") {
 		logger.Debug().Int("vmid", vmID).Msgf("Updating VM owner %v", d.Get("account").(int))
@@ -466,11 +687,211 @@ func resourceVmQemuUpdate(ctx context.Context, d *schema.ResourceData, meta inte
 		}
 		d.Set("ip_addresses", flatIpConfig)
 	}
-	var diags diag.Diagnostics
-	lock.unlock()
+	// 8. Tags
+	if d.HasChanges("tags", "tags_all") {
+		tags := mergeTags(pconf.DefaultTags, d.Get("tags").(map[string]interface{}))
+		logger.Debug().Int("vmid", vmID).Msgf("Updating VM tags %v", tags)
+		err = pconf.Api.SetVmTags(vmID, tags)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	// 9. Rescue mode
+	if d.HasChange("rescue_mode") {
+		logger.Debug().Int("vmid", vmID).Msgf("Updating VM rescue mode %v", d.Get("rescue_mode").(bool))
+		if d.Get("rescue_mode").(bool) {
+			err = pconf.Api.StartRescueMode(vmID)
+		} else {
+			err = pconf.Api.StopRescueMode(vmID)
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return diags
 }
 
+// Email notification mode of VM, or provider's default, or fallback if none is set.
+// Empty mode is not sent to VMmanager, so its own default is used
+func vmEmailMode(d *schema.ResourceData, pconf *providerConfiguration, fallback string) string {
+	mode := d.Get("email_mode").(string)
+	if mode == "" {
+		mode = pconf.EmailMode
+	}
+	if mode == "" {
+		mode = fallback
+	}
+	return emailModesApi[mode]
+}
+
+// Collect recipes with params from config
+func vmRecipesFromConfig(d *schema.ResourceData, key string) ([]vmRecipe, error) {
+	var recipes []vmRecipe
+	j, err := json.Marshal(d.Get(key).([]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(j, &recipes)
+	return recipes, err
+}
+
+func vmRecipesHaveParams(recipes []vmRecipe) bool {
+	for _, recipe := range recipes {
+		if len(recipe.RecipeParams) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func vmCustomInterfacesFromConfig(d *schema.ResourceData) []vmCustomInterface {
+	var interfaces []vmCustomInterface
+	for _, v := range d.Get("custom_interfaces").([]interface{}) {
+		iface := v.(map[string]interface{})
+		interfaces = append(interfaces, vmCustomInterface{
+			Bridge:  iface["bridge"].(string),
+			IpName:  iface["ip_name"].(string),
+			IpPool:  iface["ippool"].(int),
+			IpCount: iface["ip_count"].(int),
+		})
+	}
+	return interfaces
+}
+
+func vmVxlansFromConfig(d *schema.ResourceData) []vmVxlan {
+	var vxlans []vmVxlan
+	for _, v := range d.Get("vxlan").([]interface{}) {
+		vxlan := v.(map[string]interface{})
+		vxlans = append(vxlans, vmVxlan{
+			Id:    vxlan["id"].(int),
+			Ipnet: vxlan["ipnet"].(int),
+			IPv4:  vxlan["ipv4_number"].(int),
+		})
+	}
+	return vxlans
+}
+
+// Collect public ssh keys and ids of account ssh keys for cloud-init
+func vmSshKeysFromConfig(d *schema.ResourceData) []vmSshKey {
+	var sshKeys []vmSshKey
+	for _, key := range d.Get("ssh_keys").([]interface{}) {
+		sshKeys = append(sshKeys, vmSshKey{SshPubKey: strings.TrimSpace(key.(string))})
+	}
+	for _, id := range d.Get("ssh_key_ids").([]interface{}) {
+		sshKeys = append(sshKeys, vmSshKey{Id: id.(int)})
+	}
+	return sshKeys
+}
+
+func vmCloudInitFromConfig(d *schema.ResourceData) *vmCloudInit {
+	cloudInit := vmCloudInit{
+		User:        d.Get("user").(string),
+		UserData:    d.Get("user_data").(string),
+		NetworkData: d.Get("network_data").(string),
+	}
+	if cloudInit == (vmCloudInit{}) {
+		return nil
+	}
+	return &cloudInit
+}
+
+// Check at plan time things, that VMmanager will reject only during apply
+func resourceVmQemuCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" && d.HasChange("disk") {
+		oldValuesRaw, newValuesRaw := d.GetChange("disk")
+		if oldValuesRaw.(int) > newValuesRaw.(int) {
+			return fmt.Errorf("Can't shrink VM's disk from %v to %v", oldValuesRaw, newValuesRaw)
+		}
+	}
+	if d.Id() == "" && d.NewValueKnown("password") && d.NewValueKnown("ssh_keys") && d.NewValueKnown("ssh_key_ids") {
+		if d.Get("password").(string) == "" && !d.Get("generate_password").(bool) &&
+			len(d.Get("ssh_keys").([]interface{})) == 0 && len(d.Get("ssh_key_ids").([]interface{})) == 0 {
+			return fmt.Errorf("password is required, when ssh_keys, ssh_key_ids and generate_password are not set")
+		}
+	}
+	if d.Id() != "" && d.HasChanges(vmReinstallKeys...) {
+		protected, _ := d.GetChange("reinstall_protection")
+		if protected.(bool) {
+			return fmt.Errorf("Changing os, reinstall_trigger or cloud-init settings will reinstall VM %s, but reinstall_protection is enabled. Set reinstall_protection = false and apply before reinstall", d.Id())
+		}
+		// SDKv2 CustomizeDiff can't add warnings to plan, so it goes to log here
+		// and to apply warnings in resourceVmQemuUpdate
+		log.Printf("[WARN] VM %s will be reinstalled. All data on VM disk will be lost, as if VM was replaced", d.Id())
+	}
+	if d.NewValueKnown("tags") {
+		pconf := meta.(*providerConfiguration)
+		tags := mergeTags(pconf.DefaultTags, d.Get("tags").(map[string]interface{}))
+		if err := d.SetNew("tags_all", tags); err != nil {
+			return err
+		}
+	} else {
+		if err := d.SetNewComputed("tags_all"); err != nil {
+			return err
+		}
+	}
+
+	// Rest of checks need API, skip them until values are known
+	for _, key := range []string{"preset", "os", "disk", "cores", "memory", "node", "cluster"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+	if d.Id() != "" && !d.HasChanges("preset", "os", "disk", "cores", "memory") {
+		return nil
+	}
+
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+	defer lock.unlock()
+	api := pconf.Api
+
+	// 1. Preset will overwrite cpu/mem/disk, so they must be the same as in preset
+	if preset := d.Get("preset").(int); preset != 0 {
+		presetInfo, err := api.GetPresetInfo(preset)
+		if err != nil {
+			return fmt.Errorf("Can't get preset %v: %v", preset, err)
+		}
+		presetValues := map[string]int{
+			"cores":  mapInt(presetInfo, "cpu_number"),
+			"memory": mapInt(presetInfo, "ram_mib"),
+			"disk":   mapInt(presetInfo, "hdd_mib"),
+		}
+		// only values set in config can conflict, defaults are overwritten by preset
+		config := d.GetRawConfig()
+		for key, presetValue := range presetValues {
+			if presetValue == 0 || config.IsNull() || !config.IsKnown() || config.GetAttr(key).IsNull() {
+				continue
+			}
+			if presetValue != d.Get(key).(int) {
+				return fmt.Errorf("preset %v sets %s to %v, but %v is configured. Set %s = %v or remove preset", preset, key, presetValue, d.Get(key), key, presetValue)
+			}
+		}
+	}
+	// 2. Node must be in cluster
+	if node := d.Get("node").(int); node != 0 && (d.Id() == "" || d.HasChanges("node", "cluster")) {
+		nodeInfo, err := api.GetNodeInfo(node)
+		if err != nil {
+			return fmt.Errorf("Can't get node %v: %v", node, err)
+		}
+		nodeCluster, _ := nodeInfo["cluster"].(map[string]interface{})
+		if cluster := d.Get("cluster").(int); mapInt(nodeCluster, "id") != cluster {
+			return fmt.Errorf("node %v doesn't belong to cluster %v", node, cluster)
+		}
+	}
+	// 3. Template must fit into disk
+	if d.Id() == "" || d.HasChanges("os", "disk") {
+		osId := d.Get("os").(int)
+		osInfo, err := api.GetOsInfo(osId)
+		if err != nil {
+			return fmt.Errorf("Can't get os %v: %v", osId, err)
+		}
+		if minDisk := mapInt(osInfo, "min_disk_mib"); minDisk > d.Get("disk").(int) {
+			return fmt.Errorf("os %v needs at least %v Mb of disk, but disk is %v", osId, minDisk, d.Get("disk"))
+		}
+	}
+	return nil
+}
+
 func resourceVmQemuRead(d *schema.ResourceData, meta interface{}) error {
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
@@ -480,6 +901,10 @@ func resourceVmQemuRead(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceVmQemuDelete(d *schema.ResourceData, meta interface{}) error {
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("VM %s is protected from deletion. Set deletion_protection = false and apply before destroying it", d.Id())
+	}
+
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
 	defer lock.unlock()
@@ -510,7 +935,7 @@ func _resourceVmQemuRead(d *schema.ResourceData, meta interface{}) error {
 	// Try to get information on the vm. If this call err's out
 	// that indicates the VM does not exist. We indicate that to terraform
 	// by calling a SetId("")
-	_, err = client.GetVmInfo(vmr)
+	vmInfo, err := client.GetVmInfo(vmr)
 	if err != nil {
 		d.SetId("")
 		return nil
@@ -544,6 +969,26 @@ func _resourceVmQemuRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("domain", config.Domain)
 	d.Set("os", config.Os.Id)
 	d.Set("disk_id", config.QemuDisks.Id)
+	// rescue mode can be switched in VMmanager panel too
+	rescueMode, ok := vmInfo["rescue_mode"].(bool)
+	if !ok {
+		rescueMode = vmState == "rescue_mode"
+	}
+	d.Set("rescue_mode", rescueMode)
+
+	// tags are read only when they are used, so VMs without tags don't depend on tags API
+	if len(pconf.DefaultTags) > 0 || len(d.Get("tags").(map[string]interface{})) > 0 || len(d.Get("tags_all").(map[string]interface{})) > 0 {
+		tags, err := pconf.Api.GetVmTags(vmID)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if err != nil {
+			logger.Warn().Int("vmid", vmID).Msgf("Can't read VM tags, keeping them as is: %v", err)
+		} else {
+			d.Set("tags_all", tags)
+			d.Set("tags", withoutDefaultTags(pconf.DefaultTags, tags, d.Get("tags").(map[string]interface{})))
+		}
+	}
 
 	ipconfig, err := vm6api.NewConfigQemuIpsFromApi(vmr, client)
 	if err != nil {
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	vm6api "github.com/usaafko/vmmanager6-api-go"
 	// "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
@@ -25,6 +26,15 @@ func resourceVxlan() *schema.Resource {
 		Read:          resourceVxlanRead,
 		UpdateContext: resourceVxlanUpdate,
 		Delete:        resourceVxlanDelete,
+		CustomizeDiff: customdiff.ForceNewIfChange("clusters", func(ctx context.Context, old, new, meta interface{}) bool {
+			// VxLAN can't be removed from cluster, only added
+			for _, cluster := range old.([]interface{}) {
+				if !InterfaceIntsContains(new.([]interface{}), cluster) {
+					return true
+				}
+			}
+			return false
+		}),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -44,8 +54,7 @@ func resourceVxlan() *schema.Resource {
 			"clusters": {
 				Type:        schema.TypeList,
 				Required:    true,
-				ForceNew:    true,
-				Description: "Array of clusters id, where this VxLAN will work",
+				Description: "Array of clusters id, where this VxLAN will work. Clusters can be added in place, removing cluster recreates VxLAN",
 				Elem: &schema.Schema{
 					Type: schema.TypeInt,
 				},
@@ -85,6 +94,12 @@ func resourceVxlan() *schema.Resource {
 				Computed:    true,
 				Description: "id of Ip pool, can be used for vm creation",
 			},
+			"adopt_existing": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Manage existing VxLAN with the same name in account on create, instead of failing. Without it existing VxLAN must be imported",
+			},
 		},
 	}
 	return vxlanResource
@@ -111,6 +126,9 @@ func resourceVxlanCreate(d *schema.ResourceData, meta interface{}) error {
 	}
 	if vmid != "0" {
 		//VxLAN already exists
+		if !d.Get("adopt_existing").(bool) {
+			return adoptExistingError("VxLAN", d.Get("name").(string), vmid)
+		}
 		logger.Debug().Msgf("VxLAN already exists id %v", vmid)
 		d.SetId(vmid)
 		_resourceVxlanRead(d, meta)
@@ -159,10 +177,98 @@ func resourceVxlanCreate(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceVxlanUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
-	// TODO: Update vxlan settings
+	pconf := meta.(*providerConfiguration)
+	lock := pmParallelBegin(pconf)
+
+	defer lock.unlock()
+	// create a logger for this function
+	logger, _ := CreateSubLogger("resource_vxlan_update")
+
+	client := pconf.Client
+	logger.Info().Msg("Starting update of the VxLAN resource")
+
+	_, err := client.GetVxLANInfo(d.Id())
+	if err != nil {
+		d.SetId("")
+		return nil
+	}
+
+	if d.HasChange("comment") {
+		logger.Info().Msg("Change VxLAN comment")
+		err = pconf.Api.UpdateVxLANSettings(d.Id(), d.Get("comment").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChange("clusters") {
+		oldValuesRaw, newValuesRaw := d.GetChange("clusters")
+		oldValues := oldValuesRaw.([]interface{})
+		for _, cluster := range newValuesRaw.([]interface{}) {
+			if !InterfaceIntsContains(oldValues, cluster) {
+				logger.Debug().Msgf("Add VxLAN to cluster %v", cluster)
+				err = pconf.Api.AddVxLANCluster(d.Id(), cluster.(int))
+				if err != nil {
+					return vxlanUpdateFailed(d, meta, err)
+				}
+			}
+		}
+	}
+	if d.HasChange("ipnets") {
+		// networks are compared by name and gateway, ids are known only from state
+		oldValuesRaw, newValuesRaw := d.GetChange("ipnets")
+		oldValues := oldValuesRaw.([]interface{})
+		newValues := newValuesRaw.([]interface{})
+		// remove first, so changed gateway of the same network doesn't conflict with old one
+		for _, oldNet := range oldValues {
+			if !vxlanIpnetsContains(newValues, oldNet) {
+				ipnet := oldNet.(map[string]interface{})
+				logger.Debug().Msgf("Delete network from VxLAN %v", ipnet["name"])
+				err = pconf.Api.DeleteVxLANIpnet(ipnet["id"].(int))
+				if err != nil {
+					return vxlanUpdateFailed(d, meta, err)
+				}
+			}
+		}
+		for _, newNet := range newValues {
+			if !vxlanIpnetsContains(oldValues, newNet) {
+				ipnet := newNet.(map[string]interface{})
+				logger.Debug().Msgf("Add network to VxLAN %v", ipnet["name"])
+				err = pconf.Api.CreateVxLANIpnet(d.Id(), ipnet["name"].(string), ipnet["gateway"].(string))
+				if err != nil {
+					return vxlanUpdateFailed(d, meta, err)
+				}
+			}
+		}
+	}
+
+	// Get ids of new networks
+	err = _resourceVxlanRead(d, meta)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	logger.Info().Msg("End of update of the VxLAN resource")
 	return nil
 }
 
+// VxLAN can be changed partially, read it back so state shows what was really done
+func vxlanUpdateFailed(d *schema.ResourceData, meta interface{}, err error) diag.Diagnostics {
+	logger, _ := CreateSubLogger("resource_vxlan_update")
+	logger.Error().Msgf("Can't update VxLAN %v", err)
+	_resourceVxlanRead(d, meta)
+	return diag.FromErr(err)
+}
+
+func vxlanIpnetsContains(ipnets []interface{}, ipnet interface{}) bool {
+	net := ipnet.(map[string]interface{})
+	for _, v := range ipnets {
+		testNet := v.(map[string]interface{})
+		if testNet["name"].(string) == net["name"].(string) && testNet["gateway"].(string) == net["gateway"].(string) {
+			return true
+		}
+	}
+	return false
+}
+
 func resourceVxlanRead(d *schema.ResourceData, meta interface{}) error {
 	pconf := meta.(*providerConfiguration)
 	lock := pmParallelBegin(pconf)
@@ -188,7 +294,7 @@ func _resourceVxlanRead(d *schema.ResourceData, meta interface{}) error {
 	// create a logger for this function
 	logger, _ := CreateSubLogger("resource_vxlan_read")
 
-	_, err := client.GetVxLANInfo(d.Id())
+	vxlanInfo, err := client.GetVxLANInfo(d.Id())
 	if err != nil {
 		d.SetId("")
 		return nil
@@ -205,7 +311,12 @@ func _resourceVxlanRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("comment", config.Comment)
 	d.Set("ippool", config.Ippool)
 	d.Set("account", config.Account.Id)
-	// TODO check clusters
+	// ConfigVxLAN has no clusters, they are taken from API answer
+	clusters, err := mapIdList(vxlanInfo, "clusters")
+	if err != nil {
+		return err
+	}
+	d.Set("clusters", sortLike(d.Get("clusters").([]interface{}), clusters))
 	flatIpConfig := make([]map[string]interface{}, 0, 1)
 	for _, thisip := range config.Ips {
 		thisFlattenedIp := make(map[string]interface{})
@@ -1,13 +1,24 @@
 package vmmanager6
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rs/zerolog"
 	"io"
 	"log"
+	"math"
+	"math/big"
+	"net"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,6 +30,15 @@ var rxClusterRsId = regexp.MustCompile(`([^/]+)/([^/]+)`)
 
 var rxIPconfig = regexp.MustCompile(`ip6?=([0-9a-fA-F:\\.]+)`)
 
+// Email notification modes, and how VMmanager names them
+var emailModes = []string{"none", "owner", "admin"}
+
+var emailModesApi = map[string]string{
+	"none":  "saas_only",
+	"owner": "user_only",
+	"admin": "admin_only",
+}
+
 var macAddressRegex = regexp.MustCompile(`([a-fA-F0-9]{2}:){5}[a-fA-F0-9]{2}`)
 
 // given a string, return the appropriate zerolog level
@@ -243,6 +263,11 @@ func resourceDataToFlatValues(d *schema.ResourceData, resource *schema.Resource)
 	return flatValues, nil
 }
 
+// Objects are found by name on create. They are not taken silently, so two states don't manage the same object
+func adoptExistingError(kind string, name string, id string) error {
+	return fmt.Errorf("%s %s already exists with id %s. Import it with terraform import, or set adopt_existing = true to manage it with this resource", kind, name, id)
+}
+
 func InterfaceStringsContains(s []interface{}, str interface{}) bool {
 	for _, v := range s {
 		if v.(string) == str.(string) {
@@ -251,3 +276,376 @@ func InterfaceStringsContains(s []interface{}, str interface{}) bool {
 	}
 	return false
 }
+
+// Sort ids from API in the same order as in state, new ids go to the end in ascending order.
+// This way order of list from API doesn't make diff
+func sortLike(current []interface{}, ids []int) []int {
+	result := make([]int, 0, len(ids))
+	for _, v := range current {
+		for _, id := range ids {
+			if id == v.(int) {
+				result = append(result, id)
+				break
+			}
+		}
+	}
+	var rest []int
+	for _, id := range ids {
+		if !InterfaceIntsContains(current, id) {
+			rest = append(rest, id)
+		}
+	}
+	sort.Ints(rest)
+	return append(result, rest...)
+}
+
+func InterfaceIntsContains(s []interface{}, i interface{}) bool {
+	for _, v := range s {
+		if v.(int) == i.(int) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get int value from API answer. Json numbers are float64, ids can be strings
+func mapInt(m map[string]interface{}, key string) int {
+	switch v := m[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
+// Ids of objects from API answer list, e.g. "clusters": [{"id": 1, ...}]
+func mapIdList(m map[string]interface{}, key string) ([]int, error) {
+	if m[key] == nil {
+		return nil, nil
+	}
+	list, ok := m[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Can't parse %s from API answer: %v", key, m[key])
+	}
+	var ids []int
+	for _, v := range list {
+		item, ok := v.(map[string]interface{})
+		if !ok || item["id"] == nil {
+			return nil, fmt.Errorf("Can't parse %s from API answer: %v", key, m[key])
+		}
+		ids = append(ids, mapInt(item, "id"))
+	}
+	return ids, nil
+}
+
+const passwordHashPrefix = "pbkdf2-sha256:"
+const passwordHashIterations = 100000
+
+// Salted hash of password, to keep in state instead of password itself.
+// Format is pbkdf2-sha256:<iterations>:<salt>:<hash>
+func passwordHash(password string) string {
+	if password == "" {
+		return ""
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return ""
+	}
+	return passwordHashWithSalt(password, salt, passwordHashIterations)
+}
+
+func passwordHashWithSalt(password string, salt []byte, iterations int) string {
+	return fmt.Sprintf("%s%d:%s:%s", passwordHashPrefix, iterations, hex.EncodeToString(salt), hex.EncodeToString(pbkdf2Sha256([]byte(password), salt, iterations)))
+}
+
+// Check password against hash from state, using salt and iterations of that hash
+func passwordHashMatches(hash string, password string) bool {
+	if !strings.HasPrefix(hash, passwordHashPrefix) || password == "" {
+		return false
+	}
+	parts := strings.Split(strings.TrimPrefix(hash, passwordHashPrefix), ":")
+	if len(parts) != 3 {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[0])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(hash), []byte(passwordHashWithSalt(password, salt, iterations)))
+}
+
+// PBKDF2 (RFC 8018) with HMAC-SHA256 and key of one block
+func pbkdf2Sha256(password []byte, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+	key := make([]byte, len(u))
+	copy(key, u)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+// Password can't be removed, and with password_write_only state keeps only its hash
+func passwordDiffSuppress(k, old, new string, d *schema.ResourceData) bool {
+	return (new == "" && old != "") || passwordHashMatches(old, new)
+}
+
+// Get password from configuration, as state can keep only its hash
+func configPassword(d *schema.ResourceData) string {
+	config := d.GetRawConfig()
+	if !config.IsNull() && config.IsKnown() {
+		password := config.GetAttr("password")
+		if !password.IsNull() && password.IsKnown() {
+			return password.AsString()
+		}
+		return ""
+	}
+	// no config (e.g. import), use state
+	password := d.Get("password").(string)
+	if strings.HasPrefix(password, passwordHashPrefix) {
+		return ""
+	}
+	return password
+}
+
+// Save password or its hash to state, depending on password_write_only
+func passwordToState(d *schema.ResourceData) {
+	password := configPassword(d)
+	if password != "" && d.Get("password_write_only").(bool) {
+		password = passwordHash(password)
+	}
+	d.Set("password", password)
+}
+
+// Generate random password with lower, upper case letters, digits and special symbols
+func generatePassword(length int) (string, error) {
+	charsets := []string{
+		"abcdefghijklmnopqrstuvwxyz",
+		"ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		"0123456789",
+		"@#%^*-_+=",
+	}
+	password := make([]byte, length)
+	for i := range password {
+		// first symbols take one from each charset, so password has all of them
+		charset := charsets[i%len(charsets)]
+		if i >= len(charsets) {
+			charset = strings.Join(charsets, "")
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		password[i] = charset[n.Int64()]
+	}
+	// shuffle, so password doesn't start from predictable charsets
+	for i := len(password) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		j := n.Int64()
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// Resource tags with provider default tags. Resource tags win
+func mergeTags(defaultTags map[string]string, tags map[string]interface{}) map[string]string {
+	result := make(map[string]string)
+	for k, v := range defaultTags {
+		result[k] = v
+	}
+	for k, v := range tags {
+		result[k] = v.(string)
+	}
+	return result
+}
+
+// Tags from API without provider default tags, so they are not shown in resource tags.
+// Tags already set in resource are kept, even if they are the same as default ones
+func withoutDefaultTags(defaultTags map[string]string, tags map[string]string, resourceTags map[string]interface{}) map[string]string {
+	result := make(map[string]string)
+	for k, v := range tags {
+		_, inResource := resourceTags[k]
+		if defaultValue, ok := defaultTags[k]; ok && defaultValue == v && !inResource {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// Parse pool range in one of formats: 192.168.0.1, 192.168.0.1-192.168.0.10 or 192.168.0.0/24.
+// Returns first and last ip of range
+func parseIpRange(r string) (net.IP, net.IP, error) {
+	if strings.Contains(r, "/") {
+		_, ipnet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%q is not valid CIDR", r)
+		}
+		last := make(net.IP, len(ipnet.IP))
+		for i := range ipnet.IP {
+			last[i] = ipnet.IP[i] | ^ipnet.Mask[i]
+		}
+		return ipnet.IP, last, nil
+	}
+	parts := strings.Split(r, "-")
+	if len(parts) > 2 {
+		return nil, nil, fmt.Errorf("%q is not valid range", r)
+	}
+	first := net.ParseIP(strings.TrimSpace(parts[0]))
+	if first == nil {
+		return nil, nil, fmt.Errorf("%q is not valid ip address", parts[0])
+	}
+	last := first
+	if len(parts) == 2 {
+		last = net.ParseIP(strings.TrimSpace(parts[1]))
+		if last == nil {
+			return nil, nil, fmt.Errorf("%q is not valid ip address", parts[1])
+		}
+	}
+	if (first.To4() == nil) != (last.To4() == nil) {
+		return nil, nil, fmt.Errorf("%q mixes IPv4 and IPv6 addresses", r)
+	}
+	if first.To4() != nil {
+		first, last = first.To4(), last.To4()
+	}
+	if bytes.Compare(first, last) > 0 {
+		return nil, nil, fmt.Errorf("%q starts after it ends", r)
+	}
+	return first, last, nil
+}
+
+// Canonical form of pool range, so 10.0.0.1-10.0.0.1 and 10.0.0.1 or
+// 2001:DB8::1 and 2001:db8::1 are the same
+func normalizeIpRange(r string) string {
+	r = strings.TrimSpace(r)
+	if strings.Contains(r, "/") {
+		return normalizeCIDR(r)
+	}
+	first, last, err := parseIpRange(r)
+	if err != nil {
+		return r
+	}
+	if first.Equal(last) {
+		return first.String()
+	}
+	return first.String() + "-" + last.String()
+}
+
+func normalizeCIDR(r string) string {
+	_, ipnet, err := net.ParseCIDR(strings.TrimSpace(r))
+	if err != nil {
+		return r
+	}
+	return ipnet.String()
+}
+
+// Check if two pool ranges have common ips. Single ip is range too
+func ipRangesOverlap(a string, b string) bool {
+	firstA, lastA, err := parseIpRange(a)
+	if err != nil {
+		return false
+	}
+	firstB, lastB, err := parseIpRange(b)
+	if err != nil || len(firstA) != len(firstB) {
+		return false
+	}
+	return bytes.Compare(firstA, lastB) <= 0 && bytes.Compare(firstB, lastA) <= 0
+}
+
+func validateIpRange(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	if strings.Contains(v, "/") {
+		return validateCIDR(i, k)
+	}
+	if _, _, err := parseIpRange(v); err != nil {
+		return nil, []error{fmt.Errorf("%s: %v. Format: 192.168.0.1 or 192.168.0.1-192.168.0.10 or 192.168.0.0/24", k, err)}
+	}
+	return nil, nil
+}
+
+// CIDR must be network address, without host bits
+func validateCIDR(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+	ip, ipnet, err := net.ParseCIDR(strings.TrimSpace(v))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %q is not valid CIDR", k, v)}
+	}
+	if !ip.Equal(ipnet.IP) {
+		return nil, []error{fmt.Errorf("%s: %q has host bits set, did you mean %q", k, v, ipnet.String())}
+	}
+	return nil, nil
+}
+
+// How many ips are in pool range. Large IPv6 ranges are capped at math.MaxInt32
+func ipRangeSize(r string) (int, error) {
+	first, last, err := parseIpRange(r)
+	if err != nil {
+		return 0, err
+	}
+	size := new(big.Int).Sub(new(big.Int).SetBytes(last), new(big.Int).SetBytes(first))
+	size.Add(size, big.NewInt(1))
+	if size.Cmp(big.NewInt(math.MaxInt32)) > 0 {
+		return math.MaxInt32, nil
+	}
+	return int(size.Int64()), nil
+}
+
+// How many ips of network can be assigned to VMs. Network and broadcast
+// addresses of IPv4 networks larger than /31 and gateway are not counted
+func networkAssignableSize(cidr string, gateway string) (int, error) {
+	size, err := ipRangeSize(cidr)
+	if err != nil {
+		return 0, err
+	}
+	if size == math.MaxInt32 {
+		return size, nil
+	}
+	first, last, _ := parseIpRange(cidr)
+	if first.To4() != nil && size > 2 {
+		size -= 2
+		first, last = nextIp(first), prevIp(last)
+	}
+	gw := net.ParseIP(gateway)
+	if gw != nil && len(gw.To4()) == len(first) {
+		gw = gw.To4()
+	}
+	if gw != nil && len(gw) == len(first) && bytes.Compare(first, gw) <= 0 && bytes.Compare(gw, last) <= 0 {
+		size--
+	}
+	return size, nil
+}
+
+func nextIp(ip net.IP) net.IP {
+	next := new(big.Int).Add(new(big.Int).SetBytes(ip), big.NewInt(1)).Bytes()
+	return append(make(net.IP, len(ip)-len(next)), next...)
+}
+
+func prevIp(ip net.IP) net.IP {
+	prev := new(big.Int).Sub(new(big.Int).SetBytes(ip), big.NewInt(1)).Bytes()
+	return append(make(net.IP, len(ip)-len(prev)), prev...)
+}
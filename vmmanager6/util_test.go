@@ -0,0 +1,161 @@
+package vmmanager6
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestParseIpRange(t *testing.T) {
+	tests := []struct {
+		r     string
+		first string
+		last  string
+		err   bool
+	}{
+		{r: "10.0.0.1", first: "10.0.0.1", last: "10.0.0.1"},
+		{r: "10.0.0.1-10.0.0.10", first: "10.0.0.1", last: "10.0.0.10"},
+		{r: " 10.0.0.1 - 10.0.0.10 ", first: "10.0.0.1", last: "10.0.0.10"},
+		{r: "10.0.0.0/24", first: "10.0.0.0", last: "10.0.0.255"},
+		{r: "10.0.0.5/24", first: "10.0.0.0", last: "10.0.0.255"},
+		{r: "2001:db8::1", first: "2001:db8::1", last: "2001:db8::1"},
+		{r: "2001:db8::1-2001:db8::ff", first: "2001:db8::1", last: "2001:db8::ff"},
+		{r: "2001:db8::/120", first: "2001:db8::", last: "2001:db8::ff"},
+		{r: "10.0.0.10-10.0.0.1", err: true},
+		{r: "2001:db8::ff-2001:db8::1", err: true},
+		{r: "10.0.0.1-2001:db8::1", err: true},
+		{r: "10.0.0.1-10.0.0.2-10.0.0.3", err: true},
+		{r: "10.0.0.256", err: true},
+		{r: "10.0.0.0/33", err: true},
+		{r: "", err: true},
+	}
+	for _, tt := range tests {
+		first, last, err := parseIpRange(tt.r)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseIpRange(%q) expected error, got %v-%v", tt.r, first, last)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseIpRange(%q) unexpected error: %v", tt.r, err)
+			continue
+		}
+		if first.String() != tt.first || last.String() != tt.last {
+			t.Errorf("parseIpRange(%q) = %v-%v, want %v-%v", tt.r, first, last, tt.first, tt.last)
+		}
+	}
+}
+
+func TestNormalizeIpRange(t *testing.T) {
+	tests := []struct {
+		r    string
+		want string
+	}{
+		{r: "10.0.0.1", want: "10.0.0.1"},
+		{r: "10.0.0.1-10.0.0.1", want: "10.0.0.1"},
+		{r: " 10.0.0.1 - 10.0.0.10 ", want: "10.0.0.1-10.0.0.10"},
+		{r: "10.0.0.5/24", want: "10.0.0.0/24"},
+		{r: "2001:DB8::1", want: "2001:db8::1"},
+		{r: "2001:db8:0:0::1-2001:DB8::FF", want: "2001:db8::1-2001:db8::ff"},
+		{r: "2001:DB8::/64", want: "2001:db8::/64"},
+		{r: "10.0.0.10-10.0.0.1", want: "10.0.0.10-10.0.0.1"},
+		{r: "10.0.0.1-2001:db8::1", want: "10.0.0.1-2001:db8::1"},
+	}
+	for _, tt := range tests {
+		if got := normalizeIpRange(tt.r); got != tt.want {
+			t.Errorf("normalizeIpRange(%q) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestIpRangesOverlap(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "10.0.0.1-10.0.0.10", b: "10.0.0.10-10.0.0.20", want: true},
+		{a: "10.0.0.1-10.0.0.10", b: "10.0.0.11-10.0.0.20", want: false},
+		{a: "10.0.0.5", b: "10.0.0.1-10.0.0.10", want: true},
+		{a: "10.0.0.0/24", b: "10.0.0.128/25", want: true},
+		{a: "10.0.0.0/25", b: "10.0.0.128/25", want: false},
+		{a: "2001:db8::/120", b: "2001:db8::ff", want: true},
+		{a: "2001:db8::/120", b: "2001:db8::100", want: false},
+		{a: "10.0.0.1", b: "::ffff:10.0.0.1", want: true},
+		{a: "10.0.0.0/24", b: "2001:db8::/120", want: false},
+		{a: "10.0.0.10-10.0.0.1", b: "10.0.0.5", want: false},
+	}
+	for _, tt := range tests {
+		if got := ipRangesOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("ipRangesOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := ipRangesOverlap(tt.b, tt.a); got != tt.want {
+			t.Errorf("ipRangesOverlap(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestIpRangeSize(t *testing.T) {
+	tests := []struct {
+		r    string
+		want int
+		err  bool
+	}{
+		{r: "10.0.0.1", want: 1},
+		{r: "10.0.0.1-10.0.0.10", want: 10},
+		{r: "10.0.0.250-10.0.1.5", want: 12},
+		{r: "10.0.0.0/24", want: 256},
+		{r: "0.0.0.0/0", want: math.MaxInt32},
+		{r: "2001:db8::1-2001:db8::ff", want: 255},
+		{r: "2001:db8::/120", want: 256},
+		{r: "2001:db8::/64", want: math.MaxInt32},
+		{r: "10.0.0.10-10.0.0.1", err: true},
+		{r: "10.0.0.1-2001:db8::1", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ipRangeSize(tt.r)
+		if tt.err {
+			if err == nil {
+				t.Errorf("ipRangeSize(%q) expected error, got %v", tt.r, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ipRangeSize(%q) = %v, %v, want %v", tt.r, got, err, tt.want)
+		}
+	}
+}
+
+func TestMapIdList(t *testing.T) {
+	tests := []struct {
+		answer string
+		ids    []int
+		err    bool
+	}{
+		{answer: `{"clusters": [{"id": 1, "name": "a"}, {"id": 2}]}`, ids: []int{1, 2}},
+		{answer: `{"clusters": []}`},
+		{answer: `{}`},
+		{answer: `{"clusters": null}`},
+		{answer: `{"clusters": [1, 2]}`, err: true},
+		{answer: `{"clusters": [{"name": "a"}]}`, err: true},
+		{answer: `{"clusters": {"id": 1}}`, err: true},
+	}
+	for _, tt := range tests {
+		var answer map[string]interface{}
+		if err := json.Unmarshal([]byte(tt.answer), &answer); err != nil {
+			t.Fatal(err)
+		}
+		ids, err := mapIdList(answer, "clusters")
+		if tt.err {
+			if err == nil {
+				t.Errorf("mapIdList(%s) expected error, got %v", tt.answer, ids)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(ids) != fmt.Sprint(tt.ids) {
+			t.Errorf("mapIdList(%s) = %v, %v, want %v", tt.answer, ids, err, tt.ids)
+		}
+	}
+}